@@ -6,7 +6,6 @@ import (
 	"github.com/getkin/kin-openapi/openapi3"
 	"github.com/imdario/mergo"
 	"github.com/vasusheoran/go-openapi/scan"
-	"gopkg.in/yaml.v3"
 	"io/ioutil"
 	"log"
 	"strings"
@@ -29,8 +28,9 @@ func (s *InputSlice) Set(value string) error {
 }
 
 var logger = scan.NewLogger(scan.LogLevelInfo)
-var output, level, meta string
+var output, level, meta, genericNaming, typeMappings, requiredPolicy, nullablePolicy, openAPIVersion, failOn string
 var values, dir InputSlice
+var hoistAnonymous bool
 
 func main() {
 	flag.Var(&dir, "dir", "the directory list containing the Go files to parse")
@@ -38,6 +38,13 @@ func main() {
 	flag.StringVar(&output, "output", "./openapi.yaml", "the file path where the OpenAPI specification file will be written, default is 'openapi.yaml'")
 	flag.Var(&values, "values", "comma separated list of override spec files")
 	flag.StringVar(&meta, "meta", "", "the file path that OpenAPI meta relative to the dir")
+	flag.StringVar(&typeMappings, "types", "", "the YAML or JSON file mapping qualified Go types to schemas")
+	flag.StringVar(&genericNaming, "generic-naming", string(scan.GenericNamingConcat), "the naming scheme for instantiated generic types: `concat`, `underscore` or `of`")
+	flag.StringVar(&requiredPolicy, "required", string(scan.RequiredPolicyExplicit), "the policy for required fields without annotation: `explicit`, `omitempty` or `pointer`")
+	flag.StringVar(&nullablePolicy, "nullable", string(scan.NullablePolicyExplicit), "the policy for nullable fields without annotation: `explicit`, `pointer` or `nil`")
+	flag.StringVar(&openAPIVersion, "openapi-version", "3.1.0", "the OpenAPI version of the spec, `3.0.x` marks nullable schemas with `nullable` and `3.1.x` with the `null` type")
+	flag.BoolVar(&hoistAnonymous, "hoist-anonymous", false, "hoist anonymous struct fields into components named after the struct and the field")
+	flag.StringVar(&failOn, "fail-on", "", "fails without writing the spec if diagnostics of the severity `warning` or `error` or above are reported")
 	flag.Parse()
 
 	if len(level) != 0 {
@@ -81,7 +88,27 @@ func generateSpec() (*openapi3.T, error) {
 		dirList = append(dirList, d)
 	}
 
-	return scan.NewParser(logger).WithMetaPath(meta).GetSpec(dirList)
+	parser := scan.NewParser(logger).WithMetaPath(meta).WithGenericNaming(scan.GenericNaming(genericNaming)).
+		WithHoistAnonymous(hoistAnonymous).WithRequiredPolicy(scan.RequiredPolicy(requiredPolicy)).
+		WithNullablePolicy(scan.NullablePolicy(nullablePolicy)).WithOpenAPIVersion(openAPIVersion)
+	if len(typeMappings) != 0 {
+		if err := parser.LoadTypeMappings(typeMappings); err != nil {
+			return nil, err
+		}
+	}
+	spec, err := parser.GetSpec(dirList)
+	if err != nil || len(failOn) == 0 {
+		return spec, err
+	}
+
+	severity, err := scan.ParseSeverity(failOn)
+	if err != nil {
+		return nil, err
+	}
+	if n := parser.CountDiagnostics(severity); n > 0 {
+		return nil, fmt.Errorf("%d diagnostics with severity %s or above reported", n, severity)
+	}
+	return spec, nil
 }
 
 func mergeSpec(spec *openapi3.T) (*openapi3.T, error) {
@@ -110,8 +137,7 @@ func mergeSpec(spec *openapi3.T) (*openapi3.T, error) {
 }
 
 func writeSpec(spec *openapi3.T) error {
-
-	b, err := yaml.Marshal(spec)
+	b, err := scan.ToYAML(spec)
 	if err != nil {
 		return err
 	}
@@ -2,11 +2,13 @@ package main
 
 import (
 	"os"
+	"path/filepath"
 	"testing"
 )
 
 func Test_merge(t *testing.T) {
-	os.Args = []string{"cmd", "--dir", "../scan/testdata/pets", "--values", "../scan/testdata/pets/override.yaml", "--output", "openapi-merged.yaml"}
+	os.Args = []string{"cmd", "--dir", "../scan/testdata/pets", "--values", "../scan/testdata/pets/override.yaml",
+		"--output", filepath.Join(t.TempDir(), "openapi-merged.yaml")}
 	main()
 }
 
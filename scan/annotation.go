@@ -0,0 +1,325 @@
+package scan
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"strconv"
+	"strings"
+)
+
+// annotationPrefix starts every directive in a comment.
+const annotationPrefix = "openapi:"
+
+// knownDirectives are the names of the annotations read by the parser.
+var knownDirectives = map[string]bool{
+	"meta": true, "operation": true, "summary": true, "description": true, "tag": true, "consumes": true,
+	"produces": true, "body": true, "response": true, "param": true, "schema": true, "xml": true,
+	"discriminator": true, "implements": true, "type": true, "example": true, "deprecated": true,
+	"nullable": true, "required": true, "format": true, "name": true, "default": true, "enum": true,
+	"oneOf": true, "anyOf": true, "allOf": true, "title": true, "readOnly": true, "writeOnly": true,
+	"yaml": true, "basePath": true, "security": true,
+}
+
+// isKnownDirective reports whether name is one of the knownDirectives or constraintKeywords.
+func isKnownDirective(name string) bool {
+	return knownDirectives[name] || isConstraint(name)
+}
+
+// descriptionSeparator separates the arguments of a directive from its description.
+const descriptionSeparator = "---"
+
+// directive is an annotation parsed from a comment, e.g. `openapi:param id path string true --- ID of the pet`.
+type directive struct {
+	// Name is the annotation without the openapi: prefix, e.g. param
+	Name string
+	// Text is the raw text following the name, including its continuation lines
+	Text string
+	// Args are the positional arguments with quotes and escape sequences resolved
+	Args []string
+	// Options are the arguments written as key=value
+	Options map[string]string
+	// Description is the text following the --- separator
+	Description string
+	// Block is set for directives ending with start, whose Lines are the comment lines up to the matching end
+	Block bool
+	Lines []string
+	// Pos is the position of the openapi: prefix
+	Pos token.Pos
+	// Err is set if the arguments could not be tokenized, e.g. for an unterminated quote
+	Err error
+
+	// ends are the offsets in Text after each argument and separator the offset of ---
+	ends      []int
+	separator int
+}
+
+// value returns the text of free text directives like description, including any ---. A quoted text is unquoted.
+func (d *directive) value() string {
+	return unquoteText(d.Text)
+}
+
+// rest returns the text following the first n arguments of the directive up to its description. A quoted
+// text is unquoted.
+func (d *directive) rest(n int) string {
+	if n > len(d.ends) {
+		return ""
+	}
+	start := 0
+	if n > 0 {
+		start = d.ends[n-1]
+	}
+	return unquoteText(d.Text[start:d.separator])
+}
+
+func unquoteText(text string) string {
+	text = strings.TrimSpace(text)
+	if len(text) > 1 && (text[0] == '"' || text[0] == '`') {
+		if unquoted, err := strconv.Unquote(text); err == nil {
+			return unquoted
+		}
+	}
+	return strings.Trim(text, "\"")
+}
+
+// arg returns the positional argument i of the directive or an empty string.
+func (d *directive) arg(i int) string {
+	if i >= 0 && i < len(d.Args) {
+		return d.Args[i]
+	}
+	return ""
+}
+
+// parseDirectives returns the directives of the comment group in order. A directive starts a comment line with
+// `openapi:` and continues on the following lines that are indented, e.g. `//   continued`, including the
+// code blocks gofmt makes of them, i.e. indented lines following blank lines. Directives
+// ending with the argument start collect the following lines up to the directive of the same name ending
+// with end, e.g. `openapi:meta info description start`.
+func parseDirectives(cg *ast.CommentGroup) []*directive {
+	if cg == nil {
+		return nil
+	}
+
+	var directives []*directive
+	var current *directive
+	lines := commentLines(cg)
+	for i, line := range lines {
+		text := strings.TrimSpace(line.text)
+
+		if current != nil && current.Block {
+			if !strings.HasPrefix(text, annotationPrefix) {
+				current.Lines = append(current.Lines, line.text)
+				continue
+			}
+			// Any other directive ends the block as well
+			current.Err = nil
+			if d := newDirective(text, line.pos(text)); d.Name == current.Name && d.arg(len(d.Args)-1) == "end" {
+				current = nil
+				continue
+			}
+		}
+
+		if strings.HasPrefix(text, annotationPrefix) {
+			current = newDirective(text, line.pos(text))
+			if current.arg(len(current.Args)-1) == "start" {
+				current.Block = true
+				current.Args = current.Args[:len(current.Args)-1]
+				current.Err = fmt.Errorf("missing openapi:%s end", current.Name)
+			}
+			directives = append(directives, current)
+			continue
+		}
+
+		if current != nil && len(text) > 0 && isContinuation(line.text) {
+			current.Text += " " + text
+			current.tokenize()
+			continue
+		}
+		if current != nil && len(text) == 0 && continuesAfterBlank(lines[i+1:]) {
+			continue
+		}
+		current = nil
+	}
+	return directives
+}
+
+// findDirective returns the first directive name of the comment group, or nil.
+func findDirective(cg *ast.CommentGroup, name string) *directive {
+	for _, d := range parseDirectives(cg) {
+		if d.Name == name {
+			return d
+		}
+	}
+	return nil
+}
+
+func newDirective(text string, pos token.Pos) *directive {
+	name, rest := strings.TrimPrefix(text, annotationPrefix), ""
+	if i := strings.IndexAny(name, " \t"); i >= 0 {
+		name, rest = name[:i], name[i+1:]
+	}
+	d := parseArguments(rest)
+	d.Name, d.Pos = name, pos
+	return d
+}
+
+// parseArguments tokenizes the text following the name of a directive.
+func parseArguments(text string) *directive {
+	d := &directive{Text: strings.TrimSpace(text)}
+	d.tokenize()
+	return d
+}
+
+// continuesAfterBlank reports whether the first line of lines that is not blank is a continuation.
+func continuesAfterBlank(lines []commentLine) bool {
+	for _, line := range lines {
+		if len(strings.TrimSpace(line.text)) > 0 {
+			return isContinuation(line.text)
+		}
+	}
+	return false
+}
+
+// isContinuation reports whether the comment line is indented beyond the space following the comment marker.
+func isContinuation(line string) bool {
+	return len(line) > 0 && isSpace(line[0])
+}
+
+// tokenize splits the text of the directive into its arguments, options and description. Arguments are
+// separated by white space and may be quoted with double quotes, which resolve escape sequences, or back
+// quotes. An unquoted = in an argument makes it an option. The first unquoted --- ends the arguments.
+func (d *directive) tokenize() {
+	d.Args, d.Options, d.Description, d.ends, d.Err = nil, nil, "", nil, nil
+	d.separator = len(d.Text)
+
+	text := d.Text
+	for i := 0; i < len(text); {
+		if isSpace(text[i]) {
+			i++
+			continue
+		}
+		if strings.HasPrefix(text[i:], descriptionSeparator) && (i+3 == len(text) || isSpace(text[i+3])) {
+			d.separator = i
+			d.Description = strings.TrimSpace(text[i+3:])
+			return
+		}
+
+		var sb strings.Builder
+		key, isOption := "", false
+		for i < len(text) && !isSpace(text[i]) {
+			switch c := text[i]; c {
+			case '"', '`':
+				end, err := unquote(&sb, text, i)
+				if err != nil {
+					d.Args, d.Options, d.Err = nil, nil, err
+					return
+				}
+				i = end
+			case '=':
+				if !isOption && sb.Len() > 0 {
+					key, isOption = sb.String(), true
+					sb.Reset()
+				} else {
+					sb.WriteByte(c)
+				}
+				i++
+			default:
+				sb.WriteByte(c)
+				i++
+			}
+		}
+
+		if isOption {
+			if d.Options == nil {
+				d.Options = map[string]string{}
+			}
+			d.Options[key] = sb.String()
+		} else {
+			d.Args = append(d.Args, sb.String())
+		}
+		d.ends = append(d.ends, i)
+	}
+}
+
+// unquote writes the string quoted at text[start] to sb and returns the offset following the closing quote.
+func unquote(sb *strings.Builder, text string, start int) (int, error) {
+	quote := text[start]
+	for i := start + 1; i < len(text); i++ {
+		c := text[i]
+		switch {
+		case c == quote:
+			return i + 1, nil
+		case c == '\\' && quote == '"' && i+1 < len(text):
+			i++
+			switch text[i] {
+			case 'n':
+				sb.WriteByte('\n')
+			case 't':
+				sb.WriteByte('\t')
+			case '"', '\\':
+				sb.WriteByte(text[i])
+			default:
+				sb.WriteByte('\\')
+				sb.WriteByte(text[i])
+			}
+		default:
+			sb.WriteByte(c)
+		}
+	}
+	return 0, fmt.Errorf("unterminated quote at %q", text[start:])
+}
+
+// quoteArg quotes s if it cannot be written as an unquoted argument.
+func quoteArg(s string) string {
+	if len(s) == 0 || strings.ContainsAny(s, " \t\"`=\\") {
+		return strconv.Quote(s)
+	}
+	return s
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t'
+}
+
+// commentLine is a line of a comment without the comment markers.
+type commentLine struct {
+	text string
+	// start is the position of text
+	start token.Pos
+}
+
+// pos returns the position of trimmed in the line.
+func (l commentLine) pos(trimmed string) token.Pos {
+	if !l.start.IsValid() {
+		return token.NoPos
+	}
+	return l.start + token.Pos(strings.Index(l.text, trimmed))
+}
+
+// commentLines splits the comments of the group into lines.
+func commentLines(cg *ast.CommentGroup) []commentLine {
+	var lines []commentLine
+	for _, comment := range cg.List {
+		pos := func(offset int) token.Pos {
+			if !comment.Slash.IsValid() {
+				return token.NoPos
+			}
+			return comment.Slash + token.Pos(offset)
+		}
+
+		if strings.HasPrefix(comment.Text, "//") {
+			text := strings.TrimPrefix(comment.Text[2:], " ")
+			lines = append(lines, commentLine{text: text, start: pos(len(comment.Text) - len(text))})
+			continue
+		}
+
+		// Block comments are split into lines, the first line follows /*
+		text := strings.TrimSuffix(strings.TrimPrefix(comment.Text, "/*"), "*/")
+		offset := 2
+		for _, line := range strings.Split(text, "\n") {
+			lines = append(lines, commentLine{text: line, start: pos(offset)})
+			offset += len(line) + 1
+		}
+	}
+	return lines
+}
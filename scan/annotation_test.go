@@ -0,0 +1,243 @@
+package scan
+
+import (
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"reflect"
+	"testing"
+)
+
+func TestParseDirectives(t *testing.T) {
+	tests := []struct {
+		name     string
+		comments []string
+		want     []*directive
+	}{
+		{
+			name:     "arguments and description",
+			comments: []string{"// openapi:param id path string true --- ID of the pet --- not a separator"},
+			want: []*directive{{
+				Name:        "param",
+				Args:        []string{"id", "path", "string", "true"},
+				Description: "ID of the pet --- not a separator",
+			}},
+		},
+		{
+			name:     "quoted arguments",
+			comments: []string{`// openapi:meta info title "Pet \"Store\"	API" ` + "`raw\\n`"},
+			want: []*directive{{
+				Name: "meta",
+				Args: []string{"info", "title", "Pet \"Store\"\tAPI", `raw\n`},
+			}},
+		},
+		{
+			name:     "quoted separator",
+			comments: []string{`// openapi:response 200 "a --- b"`},
+			want: []*directive{{
+				Name: "response",
+				Args: []string{"200", "a --- b"},
+			}},
+		},
+		{
+			name:     "options",
+			comments: []string{`// openapi:discriminator kind cat=Cat "big cat"="Big Cat"`},
+			want: []*directive{{
+				Name:    "discriminator",
+				Args:    []string{"kind"},
+				Options: map[string]string{"cat": "Cat", "big cat": "Big Cat"},
+			}},
+		},
+		{
+			name: "continuation",
+			comments: []string{
+				"// openapi:param id path string true --- ID",
+				"//   of the pet",
+				"//\tto fetch",
+				"// Not a continuation",
+				"//   neither",
+			},
+			want: []*directive{{
+				Name:        "param",
+				Args:        []string{"id", "path", "string", "true"},
+				Description: "ID of the pet to fetch",
+			}},
+		},
+		{
+			name: "tabs",
+			comments: []string{
+				"// openapi:tag\tpets",
+			},
+			want: []*directive{{
+				Name: "tag",
+				Args: []string{"pets"},
+			}},
+		},
+		{
+			name: "block",
+			comments: []string{
+				"// openapi:meta info description start",
+				"// First line",
+				"//",
+				"//\topenapi-like text",
+				"// openapi:meta info description end",
+				"// openapi:meta info version 1.0.0",
+			},
+			want: []*directive{
+				{
+					Name:  "meta",
+					Args:  []string{"info", "description"},
+					Block: true,
+					Lines: []string{"First line", "", "\topenapi-like text"},
+				},
+				{
+					Name: "meta",
+					Args: []string{"info", "version", "1.0.0"},
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cg := &ast.CommentGroup{}
+			for _, text := range tt.comments {
+				cg.List = append(cg.List, &ast.Comment{Text: text})
+			}
+
+			got := parseDirectives(cg)
+			if len(got) != len(tt.want) {
+				t.Fatalf("parseDirectives() returned %d directives, want %d", len(got), len(tt.want))
+			}
+			for i, d := range got {
+				if d.Err != nil {
+					t.Errorf("directive %s error = %v", d.Name, d.Err)
+				}
+				want := tt.want[i]
+				if d.Name != want.Name || !reflect.DeepEqual(d.Args, want.Args) || !reflect.DeepEqual(d.Options, want.Options) ||
+					d.Description != want.Description || d.Block != want.Block || !reflect.DeepEqual(d.Lines, want.Lines) {
+					t.Errorf("parseDirectives()[%d] = %+v, want %+v", i, d, want)
+				}
+			}
+		})
+	}
+}
+
+func TestParseDirectives_Errors(t *testing.T) {
+	tests := []struct {
+		name     string
+		comments []string
+	}{
+		{
+			name:     "unterminated quote",
+			comments: []string{`// openapi:operation GET "/pets listPets`},
+		},
+		{
+			name:     "unterminated block",
+			comments: []string{"// openapi:meta info description start", "// text"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cg := &ast.CommentGroup{}
+			for _, text := range tt.comments {
+				cg.List = append(cg.List, &ast.Comment{Text: text})
+			}
+			got := parseDirectives(cg)
+			if len(got) != 1 || got[0].Err == nil {
+				t.Errorf("parseDirectives() = %+v, want an error", got)
+			}
+		})
+	}
+}
+
+func TestDirective_value(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{text: `// openapi:description Name of the pet --- "the" name`, want: `Name of the pet --- "the" name`},
+		{text: `// openapi:example "rambo"`, want: "rambo"},
+		{text: `// openapi:example "line\nbreak"`, want: "line\nbreak"},
+		{text: `// openapi:pattern ^\d+$`, want: `^\d+$`},
+		{text: `// openapi:description 5" pipe`, want: `5" pipe`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			d := parseDirectives(&ast.CommentGroup{List: []*ast.Comment{{Text: tt.text}}})[0]
+			if got := d.value(); got != tt.want {
+				t.Errorf("value() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseDirectives_Pos(t *testing.T) {
+	src := `package p
+
+// Pet ...
+/*
+   openapi:schema pet
+*/
+type Pet struct {
+	// openapi:description Name
+	Name string
+}
+`
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "pet.go", src, parser.ParseComments)
+	if err != nil {
+		t.Fatalf("ParseFile() error = %v", err)
+	}
+
+	want := []string{"pet.go:5:4", "pet.go:8:5"}
+	for i, cg := range file.Comments {
+		d := parseDirectives(cg)
+		if len(d) != 1 {
+			t.Fatalf("comment %d has %d directives, want 1", i, len(d))
+		}
+		if got := fset.Position(d[0].Pos).String(); got != want[i] {
+			t.Errorf("position = %s, want %s", got, want[i])
+		}
+	}
+}
+
+func TestParseDirectives_Gofmt(t *testing.T) {
+	src := `package p
+
+// GetPet Fetches a pet
+// openapi:operation GET /pets/{id} getPet
+// openapi:description Returns the pet
+//   fetched by id
+//
+//   or by name
+// openapi:response 200 Pet --- The pet
+//   with its owner
+//
+// Not a continuation
+//   neither
+func GetPet() {}
+`
+	formatted, err := format.Source([]byte(src))
+	if err != nil {
+		t.Fatalf("Source() error = %v", err)
+	}
+	file, err := parser.ParseFile(token.NewFileSet(), "pet.go", formatted, parser.ParseComments)
+	if err != nil {
+		t.Fatalf("ParseFile() error = %v", err)
+	}
+
+	got := parseDirectives(file.Decls[0].(*ast.FuncDecl).Doc)
+	if len(got) != 3 {
+		t.Fatalf("parseDirectives() returned %d directives, want 3", len(got))
+	}
+	if want := "Returns the pet fetched by id or by name"; got[1].value() != want {
+		t.Errorf("description = %q, want %q", got[1].value(), want)
+	}
+	if want := "The pet with its owner"; got[2].Description != want {
+		t.Errorf("response description = %q, want %q", got[2].Description, want)
+	}
+}
@@ -0,0 +1,44 @@
+package scan
+
+import (
+	"math"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// getBasicSchema returns the schema of the predeclared Go type name or nil if it is not a basic type.
+// Integer types smaller than 64 bits are bounded by their range, unsigned types have a minimum of 0.
+func getBasicSchema(name string) *openapi3.Schema {
+	switch name {
+	case "string":
+		return &openapi3.Schema{Type: "string"}
+	case "bool":
+		return &openapi3.Schema{Type: "boolean"}
+	case "int", "int64":
+		return &openapi3.Schema{Type: "integer", Format: "int64"}
+	case "int32", "rune":
+		return &openapi3.Schema{Type: "integer", Format: "int32"}
+	case "int8":
+		return boundedInteger("int32", math.MinInt8, math.MaxInt8)
+	case "int16":
+		return boundedInteger("int32", math.MinInt16, math.MaxInt16)
+	case "uint8", "byte":
+		return boundedInteger("int32", 0, math.MaxUint8)
+	case "uint16":
+		return boundedInteger("int32", 0, math.MaxUint16)
+	case "uint32":
+		return boundedInteger("int64", 0, math.MaxUint32)
+	case "uint", "uint64", "uintptr":
+		// The maximum of uint64 exceeds int64 and is left unbounded
+		return openapi3.NewInt64Schema().WithMin(0)
+	case "float32":
+		return &openapi3.Schema{Type: "number", Format: "float"}
+	case "float64":
+		return &openapi3.Schema{Type: "number", Format: "double"}
+	}
+	return nil
+}
+
+func boundedInteger(format string, min, max float64) *openapi3.Schema {
+	return (&openapi3.Schema{Type: "integer", Format: format}).WithMin(min).WithMax(max)
+}
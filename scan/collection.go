@@ -0,0 +1,62 @@
+package scan
+
+import (
+	"go/ast"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// isCollection reports whether decl declares a named slice, array or map type.
+func isCollection(decl *typeDecl) bool {
+	switch decl.spec.Type.(type) {
+	case *ast.ArrayType, *ast.MapType:
+		return true
+	}
+	return false
+}
+
+// createCollectionSchema creates the component name for the named slice, array or map type decl. The
+// component is registered before the element type is parsed, so recursive types reference it.
+func (p *Parser) createCollectionSchema(name string, decl *typeDecl) *openapi3.Schema {
+	if schemaRef, ok := p.spec.Components.Schemas[name]; ok {
+		return schemaRef.Value
+	}
+
+	sc, ok := p.structComments[name]
+	if !ok {
+		sc = &structComment{}
+	}
+	description := sc.Description
+
+	if wire := p.getWireSchema(decl); wire != nil {
+		// Copy the schema as the wire schema is cached for the declaration
+		schema := *wire
+		if len(description) > 0 {
+			schema.Description = description
+		}
+		p.applyFragment(name, sc.Fragment, &schema)
+		p.schemaMap[name] = &schema
+		p.spec.Components.Schemas[name] = &openapi3.SchemaRef{Value: &schema}
+		return &schema
+	}
+
+	schema := &openapi3.Schema{}
+	p.schemaMap[name] = schema
+	p.spec.Components.Schemas[name] = &openapi3.SchemaRef{Value: schema}
+
+	schemaRef := p.ParseTypeExpr(name, decl.spec.Type)
+	if schemaRef == nil || schemaRef.Value == nil {
+		p.warn(decl.spec.Pos(), "", "unsupported type for schema %s", name)
+		delete(p.schemaMap, name)
+		delete(p.spec.Components.Schemas, name)
+		return nil
+	}
+	*schema = *schemaRef.Value
+	if len(description) > 0 {
+		schema.Description = description
+	}
+	p.applyFragment(name, sc.Fragment, schema)
+
+	p.logger.Debug("created collection schema %s", name)
+	return schema
+}
@@ -20,68 +20,56 @@ func (p *Parser) extractOpenAPIInfo(cg *ast.CommentGroup) {
 		return
 	}
 
-	for i := 0; i < len(cg.List); i++ {
-		text := cg.List[i].Text
-		if strings.HasPrefix(text, "// openapi:meta") {
-			fields := strings.Fields(strings.TrimPrefix(text, "// openapi:meta"))
-			switch fields[0] {
-			case "info":
-				switch fields[1] {
-				case "title": // join " " and trim \"
-					p.spec.Info.Title = strings.Trim(strings.TrimPrefix(cg.List[i].Text, "// openapi:meta info title "), "\"")
-				case "description":
-					start := i + 1
-					if fields[2] == "start" {
-						endIdx := -1
-						for i = i + 1; i < len(cg.List); i++ {
-							if strings.Contains(cg.List[i].Text, "// openapi:") {
-								endIdx = i
-								break
-							}
-						}
-						if endIdx == -1 {
-							continue
-						}
-						var sb strings.Builder
-						for i = start; i < endIdx; i++ {
-							sb.WriteString(strings.TrimSpace(cg.List[i].Text[2:]))
-							sb.WriteString("\n")
-						}
-						p.spec.Info.Description = sb.String()
-					} else {
-						p.spec.Info.Description = strings.Trim(strings.TrimPrefix(cg.List[i].Text, "// openapi:meta info description "), "\"")
-					}
-				case "version":
-					p.spec.Info.Version = strings.Trim(fields[2], "\"")
-				case "oas":
-					p.spec.OpenAPI = strings.Trim(fields[2], "\"")
-				}
-			case "tag":
-				parts := strings.Split(strings.TrimSpace(strings.TrimPrefix(text, "// openapi:meta tag ")), "---")
-				tag := &openapi3.Tag{
-					Name:        strings.TrimSpace(parts[0]),
-					Description: strings.TrimSpace(parts[1]),
-				}
-				p.spec.Tags = append(p.spec.Tags, tag)
-			case "server":
-				p.spec.Servers = openapi3.Servers{}
-				serverList := strings.Split(strings.Trim(strings.TrimPrefix(cg.List[i].Text, "// openapi:meta server "), "\""), " ")
-				for _, url := range serverList {
-					s := &openapi3.Server{
-						URL:         url,
-						Description: "",
-						Variables:   nil,
+	for _, d := range parseDirectives(cg) {
+		if d.Name != "meta" {
+			continue
+		}
+		if d.Err != nil {
+			p.fail(d.Pos, "openapi:meta", "invalid arguments: %s", d.Err)
+			continue
+		}
+
+		switch d.arg(0) {
+		case "info":
+			switch d.arg(1) {
+			case "title":
+				p.spec.Info.Title = d.rest(2)
+			case "description":
+				if d.Block {
+					var sb strings.Builder
+					for _, line := range d.Lines {
+						sb.WriteString(strings.TrimSpace(line))
+						sb.WriteString("\n")
 					}
-					p.spec.Servers = append(p.spec.Servers, s)
-				}
-			case "contact":
-				parts := strings.Split(strings.TrimSpace(strings.TrimPrefix(text, "// openapi:meta contact ")), " ")
-				contact := &openapi3.Contact{
-					URL:  parts[0],
-					Name: strings.Join(parts[2:], " "),
+					p.spec.Info.Description = sb.String()
+				} else {
+					p.spec.Info.Description = d.rest(2)
 				}
-				p.spec.Info.Contact = contact
-
+			case "version":
+				p.spec.Info.Version = d.arg(2)
+			case "oas":
+				p.spec.OpenAPI = d.arg(2)
+			}
+		case "tag":
+			p.spec.Tags = append(p.spec.Tags, &openapi3.Tag{
+				Name:        d.rest(1),
+				Description: d.Description,
+			})
+		case "server":
+			p.spec.Servers = openapi3.Servers{}
+			for _, url := range d.Args[1:] {
+				p.spec.Servers = append(p.spec.Servers, &openapi3.Server{URL: url})
+			}
+		case "securityScheme":
+			p.addSecurityScheme(d)
+		case "securityScope":
+			p.addSecurityScope(d)
+		case "security":
+			p.addDefaultSecurity(d)
+		case "contact":
+			p.spec.Info.Contact = &openapi3.Contact{
+				URL:  d.arg(1),
+				Name: d.rest(2),
 			}
 		}
 	}
@@ -0,0 +1,159 @@
+package scan
+
+import (
+	"fmt"
+	"go/ast"
+	"go/types"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// constraintKeywords are the JSON Schema keywords that can be set with field annotations, e.g. `openapi:minimum 1`.
+var constraintKeywords = []string{
+	"minimum", "maximum", "exclusiveMinimum", "exclusiveMaximum", "multipleOf",
+	"minLength", "maxLength", "pattern", "minItems", "maxItems", "uniqueItems",
+}
+
+// isConstraint reports whether the annotation name is one of the constraintKeywords.
+func isConstraint(name string) bool {
+	for _, keyword := range constraintKeywords {
+		if name == keyword {
+			return true
+		}
+	}
+	return false
+}
+
+// applyConstraints applies the constraint annotations of the field to its schema. Invalid constraints
+// are reported and skipped.
+func (p *Parser) applyConstraints(key string, field *ast.Field, fc *fieldComment, schema *openapi3.Schema) {
+	for _, keyword := range constraintKeywords {
+		value, ok := fc.Constraints[keyword]
+		if !ok {
+			continue
+		}
+		if err := p.applyConstraint(field.Type, schema, keyword, value); err != nil {
+			p.warn(fc.Positions[keyword], annotationPrefix+keyword, "invalid value for %s: %s", key, err)
+		}
+	}
+}
+
+// applyConstraint sets the keyword of schema to value converted according to the Go type expr.
+func (p *Parser) applyConstraint(expr ast.Expr, schema *openapi3.Schema, keyword, value string) error {
+	switch keyword {
+	case "minimum", "maximum", "exclusiveMinimum", "exclusiveMaximum", "multipleOf":
+		if schema.Type != "integer" && schema.Type != "number" {
+			return fmt.Errorf("%s applies to numbers, the field is %s", keyword, schema.Type)
+		}
+		var number *float64
+		if len(value) > 0 || !strings.HasPrefix(keyword, "exclusive") {
+			n, err := p.parseNumber(expr, value)
+			if err != nil {
+				return err
+			}
+			number = &n
+		}
+		switch keyword {
+		case "minimum":
+			schema.Min = number
+		case "maximum":
+			schema.Max = number
+		case "exclusiveMinimum":
+			// Without a value the minimum is exclusive
+			schema.ExclusiveMin = true
+			if number != nil {
+				schema.Min = number
+			}
+		case "exclusiveMaximum":
+			schema.ExclusiveMax = true
+			if number != nil {
+				schema.Max = number
+			}
+		case "multipleOf":
+			if *number <= 0 {
+				return fmt.Errorf("%s must be greater than 0", value)
+			}
+			schema.MultipleOf = number
+		}
+	case "minLength", "maxLength", "pattern":
+		if schema.Type != "string" {
+			return fmt.Errorf("%s applies to strings, the field is %s", keyword, schema.Type)
+		}
+		if keyword == "pattern" {
+			if _, err := regexp.Compile(value); err != nil {
+				return err
+			}
+			schema.Pattern = value
+			return nil
+		}
+		n, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			return err
+		}
+		if keyword == "minLength" {
+			schema.MinLength = n
+		} else {
+			schema.MaxLength = &n
+		}
+	case "minItems", "maxItems", "uniqueItems":
+		if schema.Type != "array" {
+			return fmt.Errorf("%s applies to arrays, the field is %s", keyword, schema.Type)
+		}
+		if keyword == "uniqueItems" {
+			unique := true
+			if len(value) > 0 {
+				var err error
+				if unique, err = strconv.ParseBool(value); err != nil {
+					return err
+				}
+			}
+			schema.UniqueItems = unique
+			return nil
+		}
+		n, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			return err
+		}
+		if keyword == "minItems" {
+			schema.MinItems = n
+		} else {
+			schema.MaxItems = &n
+		}
+	}
+	return nil
+}
+
+// parseNumber parses value as a number of the Go type expr. Integer types only accept integers.
+func (p *Parser) parseNumber(expr ast.Expr, value string) (float64, error) {
+	if p.isInteger(expr) {
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("%s is not an integer", value)
+		}
+		return float64(n), nil
+	}
+	n, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%s is not a number", value)
+	}
+	return n, nil
+}
+
+// isInteger reports whether expr is an integer type or a pointer to one.
+func (p *Parser) isInteger(expr ast.Expr) bool {
+	if t := p.typeOf(expr); t != nil {
+		if ptr, ok := t.(*types.Pointer); ok {
+			t = ptr.Elem()
+		}
+		basic, ok := t.Underlying().(*types.Basic)
+		return ok && basic.Info()&types.IsInteger != 0
+	}
+	if star, ok := expr.(*ast.StarExpr); ok {
+		expr = star.X
+	}
+	ident, ok := expr.(*ast.Ident)
+	return ok && (strings.HasPrefix(ident.Name, "int") || strings.HasPrefix(ident.Name, "uint"))
+}
@@ -0,0 +1,224 @@
+package scan
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"strconv"
+	"strings"
+)
+
+// Severity is the severity of a diagnostic.
+type Severity int
+
+const (
+	// SeverityWarning reports annotations and declarations that are documented partially or not at all.
+	SeverityWarning Severity = iota
+	// SeverityError reports annotations that are malformed, e.g. operations missing from the spec.
+	SeverityError
+)
+
+func (s Severity) String() string {
+	if s == SeverityError {
+		return "error"
+	}
+	return "warning"
+}
+
+// ParseSeverity returns the severity named `warning` or `error`.
+func ParseSeverity(name string) (Severity, error) {
+	switch name {
+	case "warning":
+		return SeverityWarning, nil
+	case "error":
+		return SeverityError, nil
+	}
+	return SeverityWarning, fmt.Errorf("unsupported severity %s, expected warning or error", name)
+}
+
+// Diagnostic is a problem found in the annotations or declarations of the scanned packages.
+type Diagnostic struct {
+	Position token.Position
+	Severity Severity
+	// Directive is the annotation the diagnostic refers to, e.g. openapi:param, if any
+	Directive string
+	Message   string
+	// Suggestion describes how to fix the problem, if known
+	Suggestion string
+}
+
+func (d *Diagnostic) String() string {
+	var sb strings.Builder
+	if d.Position.IsValid() {
+		sb.WriteString(d.Position.String())
+		sb.WriteString(": ")
+	}
+	sb.WriteString(d.Severity.String())
+	sb.WriteString(": ")
+	if len(d.Directive) > 0 {
+		sb.WriteString(d.Directive)
+		sb.WriteString(": ")
+	}
+	sb.WriteString(d.Message)
+	if len(d.Suggestion) > 0 {
+		sb.WriteString(" (")
+		sb.WriteString(d.Suggestion)
+		sb.WriteString(")")
+	}
+	return sb.String()
+}
+
+// annotationError is a malformed annotation found while parsing a comment group.
+type annotationError struct {
+	pos        token.Pos
+	directive  string
+	message    string
+	suggestion string
+}
+
+func (e *annotationError) Error() string {
+	return fmt.Sprintf("%s: %s", e.directive, e.message)
+}
+
+// Diagnostics returns the diagnostics reported while generating the spec.
+func (p *Parser) Diagnostics() []*Diagnostic {
+	return p.diagnostics
+}
+
+// CountDiagnostics returns the number of diagnostics reported with severity or above.
+func (p *Parser) CountDiagnostics(severity Severity) int {
+	var n int
+	for _, d := range p.diagnostics {
+		if d.Severity >= severity {
+			n++
+		}
+	}
+	return n
+}
+
+// warn reports a warning at pos.
+func (p *Parser) warn(pos token.Pos, directive, format string, args ...interface{}) {
+	p.report(&Diagnostic{Severity: SeverityWarning, Directive: directive, Message: fmt.Sprintf(format, args...)}, pos)
+}
+
+// fail reports an error at pos.
+func (p *Parser) fail(pos token.Pos, directive, format string, args ...interface{}) {
+	p.report(&Diagnostic{Severity: SeverityError, Directive: directive, Message: fmt.Sprintf(format, args...)}, pos)
+}
+
+func (p *Parser) report(d *Diagnostic, pos token.Pos) {
+	d.Position = p.fileSet.Position(pos)
+	p.record(d)
+}
+
+// record adds the positioned diagnostic d, unless the same diagnostic was reported before, e.g. for another use
+// of a type.
+func (p *Parser) record(d *Diagnostic) {
+	for _, reported := range p.diagnostics {
+		if *reported == *d {
+			return
+		}
+	}
+	p.diagnostics = append(p.diagnostics, d)
+	if d.Severity == SeverityError {
+		p.logger.Error("%s", d)
+	} else {
+		p.logger.Warn("%s", d)
+	}
+}
+
+// parsePosition parses a position reported by the go command or the type checker, e.g. `file.go:12:5`.
+func parsePosition(s string) token.Position {
+	var numbers []int
+	for len(numbers) < 2 {
+		i := strings.LastIndex(s, ":")
+		if i == -1 {
+			break
+		}
+		n, err := strconv.Atoi(s[i+1:])
+		if err != nil {
+			break
+		}
+		numbers, s = append([]int{n}, numbers...), s[:i]
+	}
+	if len(numbers) == 0 {
+		return token.Position{}
+	}
+	position := token.Position{Filename: s, Line: numbers[0]}
+	if len(numbers) == 2 {
+		position.Column = numbers[1]
+	}
+	return position
+}
+
+// reportAnnotationError reports the malformed annotation of err.
+func (p *Parser) reportAnnotationError(err *annotationError) {
+	p.report(&Diagnostic{
+		Severity:   SeverityError,
+		Directive:  err.directive,
+		Message:    err.message,
+		Suggestion: err.suggestion,
+	}, err.pos)
+}
+
+// checkDirectives reports the directives of the comments of file that are not known, suggesting the
+// nearest known directive.
+func (p *Parser) checkDirectives(file *ast.File) {
+	for _, cg := range file.Comments {
+		for _, d := range parseDirectives(cg) {
+			if isKnownDirective(d.Name) {
+				continue
+			}
+			diagnostic := &Diagnostic{
+				Severity:  SeverityWarning,
+				Directive: annotationPrefix + d.Name,
+				Message:   "unknown annotation is ignored",
+			}
+			if name := suggestDirective(d.Name); len(name) > 0 {
+				diagnostic.Suggestion = fmt.Sprintf("did you mean %s%s?", annotationPrefix, name)
+			}
+			p.report(diagnostic, d.Pos)
+		}
+	}
+}
+
+// suggestDirective returns the known directive nearest to name, or an empty string if none is similar.
+func suggestDirective(name string) string {
+	var suggestion string
+	// Up to a third of the name may differ
+	best := len(name)/3 + 2
+	suggest := func(known string) {
+		distance := editDistance(strings.ToLower(name), strings.ToLower(known))
+		if distance < best || distance == best && known < suggestion {
+			suggestion, best = known, distance
+		}
+	}
+	for known := range knownDirectives {
+		suggest(known)
+	}
+	for _, keyword := range constraintKeywords {
+		suggest(keyword)
+	}
+	return suggestion
+}
+
+// editDistance returns the Levenshtein distance of a and b.
+func editDistance(a, b string) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(b)]
+}
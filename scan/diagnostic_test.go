@@ -0,0 +1,90 @@
+package scan
+
+import (
+	"go/token"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestParser_Diagnostics(t *testing.T) {
+	parser := NewParser(NewLogger(LogLevelFatal))
+	spec, err := parser.GetSpec([]string{"testdata/diagnostics"})
+	if err != nil {
+		t.Fatalf("GetSpec() error = %v", err)
+	}
+
+	var got []string
+	for _, d := range parser.Diagnostics() {
+		d.Position.Filename = filepath.Base(d.Position.Filename)
+		got = append(got, d.String())
+	}
+	want := []string{
+		"diagnostics.go:58:24: warning: error loading package github.com/vasusheoran/go-openapi/scan/testdata/diagnostics: " +
+			`cannot use "calm" (untyped string constant) as Mood value in variable declaration`,
+		"diagnostics.go:6:5: warning: openapi:descripton: unknown annotation is ignored (did you mean openapi:description?)",
+		"diagnostics.go:14:5: warning: openapi:produce: unknown annotation is ignored (did you mean openapi:produces?)",
+		"diagnostics.go:20:5: error: openapi:param: invalid format in PetsInterface/GetPet, the operation is skipped " +
+			"(expected openapi:param <Name> <In> <Type> <Required> [--- Description])",
+		`diagnostics.go:26:4: error: openapi:operation: unterminated quote at "\"/pets/{id} deletePet" in DeletePet, ` +
+			"the operation is skipped (expected openapi:operation <Method> <Path> <OperationID>)",
+		"diagnostics.go:30:6: warning: String value of MoodCalm could not be resolved, Mood is documented without enum " +
+			"(return a string literal for each constant in a switch on the receiver, or index a literal with the receiver)",
+		"diagnostics.go:55:13: warning: map key of ProfileScores is not a string, the keys are serialized as JSON object keys",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Diagnostics() = %q, want %q", got, want)
+	}
+
+	if n := parser.CountDiagnostics(SeverityError); n != 2 {
+		t.Errorf("CountDiagnostics(SeverityError) = %d, want 2", n)
+	}
+	if n := parser.CountDiagnostics(SeverityWarning); n != 7 {
+		t.Errorf("CountDiagnostics(SeverityWarning) = %d, want 7", n)
+	}
+	if spec.Paths.Find("/pets") == nil || spec.Paths.Find("/pets/{id}") != nil {
+		t.Errorf("paths = %v, want only /pets", spec.Paths)
+	}
+}
+
+func TestParsePosition(t *testing.T) {
+	tests := []struct {
+		position string
+		want     token.Position
+	}{
+		{position: "/src/pets.go:12:5", want: token.Position{Filename: "/src/pets.go", Line: 12, Column: 5}},
+		{position: "/src/pets.go:12", want: token.Position{Filename: "/src/pets.go", Line: 12}},
+		{position: `C:\src\pets.go:12:5`, want: token.Position{Filename: `C:\src\pets.go`, Line: 12, Column: 5}},
+		{position: "-", want: token.Position{}},
+		{position: "", want: token.Position{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.position, func(t *testing.T) {
+			if got := parsePosition(tt.position); got != tt.want {
+				t.Errorf("parsePosition() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSuggestDirective(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{name: "produce", want: "produces"},
+		{name: "readonly", want: "readOnly"},
+		{name: "minimun", want: "minimum"},
+		{name: "operaton", want: "operation"},
+		{name: "foo", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := suggestDirective(tt.name); got != tt.want {
+				t.Errorf("suggestDirective() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
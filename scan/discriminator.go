@@ -0,0 +1,180 @@
+package scan
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"sort"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// discriminatorCheck is a discriminator whose property is validated once all schemas are resolved.
+type discriminatorCheck struct {
+	key           string
+	pos           token.Pos
+	discriminator *openapi3.Discriminator
+	variants      openapi3.SchemaRefs
+}
+
+// parseDiscriminator parses the discriminator annotation `<property> [value=Schema ...]`.
+func parseDiscriminator(text string) (*openapi3.Discriminator, error) {
+	d := parseArguments(text)
+	if d.Err != nil {
+		return nil, d.Err
+	}
+	if len(d.Args) == 0 {
+		return nil, fmt.Errorf("missing property name")
+	}
+	if len(d.Args) > 1 {
+		return nil, fmt.Errorf("invalid mapping %s, expected value=Schema", d.Args[1])
+	}
+
+	discriminator := &openapi3.Discriminator{PropertyName: d.Args[0]}
+	for value, name := range d.Options {
+		if len(name) == 0 {
+			return nil, fmt.Errorf("invalid mapping %s=, expected value=Schema", value)
+		}
+		if discriminator.Mapping == nil {
+			discriminator.Mapping = map[string]string{}
+		}
+		discriminator.Mapping[value] = fmt.Sprintf("#/components/schemas/%s", name)
+	}
+	return discriminator, nil
+}
+
+// addComposition replaces the schema of a field annotated with openapi:oneOf, openapi:anyOf or
+// openapi:allOf with the composition of the listed schemas and adds the discriminator of the field.
+func (p *Parser) addComposition(key string, field *ast.Field, fieldSchemaRef *openapi3.SchemaRef, fc *fieldComment) {
+	if fc == nil {
+		return
+	}
+
+	schema := &openapi3.Schema{}
+	var variants openapi3.SchemaRefs
+	switch {
+	case len(fc.OneOf) > 0:
+		p.logger.Debug("found openapi:oneOf")
+		variants = p.getVariantRefs(key, field, fc.OneOf)
+		schema.OneOf = variants
+	case len(fc.AnyOf) > 0:
+		p.logger.Debug("found openapi:anyOf")
+		variants = p.getVariantRefs(key, field, fc.AnyOf)
+		schema.AnyOf = variants
+	case len(fc.AllOf) > 0:
+		p.logger.Debug("found openapi:allOf")
+		variants = p.getVariantRefs(key, field, fc.AllOf)
+		schema.AllOf = variants
+	default:
+		if len(fc.Discriminator) > 0 {
+			p.warn(fc.Positions["discriminator"], "openapi:discriminator", "%s requires openapi:oneOf, openapi:anyOf or openapi:allOf", key)
+		}
+		return
+	}
+
+	if len(fc.Discriminator) > 0 {
+		p.addDiscriminator(key, fc.Positions["discriminator"], schema, fc.Discriminator, variants)
+	}
+	if fieldSchemaRef.Value != nil {
+		schema.Description = fieldSchemaRef.Value.Description
+		schema.Nullable = fieldSchemaRef.Value.Nullable
+	}
+	fieldSchemaRef.Value = schema
+	fieldSchemaRef.Ref = ""
+}
+
+// getVariantRefs returns references to the schemas named in a composition annotation of the field.
+// The names are openapi:schema names or Go types visible from the package of the field.
+func (p *Parser) getVariantRefs(key string, field *ast.Field, names []string) openapi3.SchemaRefs {
+	var refs openapi3.SchemaRefs
+	for _, name := range names {
+		if ts, ok := p.typeMap[name]; ok && p.structMap[name] != nil {
+			refs = append(refs, &openapi3.SchemaRef{
+				Ref:   fmt.Sprintf("#/components/schemas/%s", name),
+				Value: p.createOpenAPISchema(name, ts),
+			})
+			continue
+		}
+		if obj := p.lookupTypeName(p.packageOf(field.Pos()), name); obj != nil {
+			if ref := p.parseNamedType(name, obj); ref != nil && len(ref.Ref) > 0 {
+				refs = append(refs, ref)
+				continue
+			}
+		}
+
+		// Continue with a warning
+		p.warn(field.Pos(), "", "schema %s of %s not found", name, key)
+		refs = append(refs, openapi3.NewSchemaRef(fmt.Sprintf("#/components/schemas/%s", name), nil))
+	}
+	return refs
+}
+
+// addDiscriminator sets the discriminator annotation text on schema. The discriminator property is
+// validated against the variants once all schemas are resolved.
+func (p *Parser) addDiscriminator(key string, pos token.Pos, schema *openapi3.Schema, text string, variants openapi3.SchemaRefs) {
+	discriminator, err := parseDiscriminator(text)
+	if err != nil {
+		p.warn(pos, "openapi:discriminator", "invalid value for %s: %s", key, err)
+		return
+	}
+	schema.Discriminator = discriminator
+	p.discriminators = append(p.discriminators, &discriminatorCheck{
+		key:           key,
+		pos:           pos,
+		discriminator: discriminator,
+		variants:      variants,
+	})
+}
+
+// validateDiscriminators reports variants and mapped schemas missing the discriminator property.
+func (p *Parser) validateDiscriminators() {
+	for _, check := range p.discriminators {
+		refs := check.variants
+		for _, value := range sortedMappingValues(check.discriminator.Mapping) {
+			refs = append(refs, openapi3.NewSchemaRef(check.discriminator.Mapping[value], nil))
+		}
+
+		for _, ref := range refs {
+			name := strings.TrimPrefix(ref.Ref, "#/components/schemas/")
+			schemaRef := ref
+			if schemaRef.Value == nil {
+				schemaRef = p.spec.Components.Schemas[name]
+			}
+			if schemaRef == nil || schemaRef.Value == nil {
+				p.warn(check.pos, "openapi:discriminator", "schema %s of %s not found", name, check.key)
+				continue
+			}
+			if !hasProperty(schemaRef.Value, check.discriminator.PropertyName, map[*openapi3.Schema]bool{}) {
+				p.warn(check.pos, "openapi:discriminator", "schema %s has no discriminator property %s of %s",
+					name, check.discriminator.PropertyName, check.key)
+			}
+		}
+	}
+}
+
+// hasProperty reports whether schema or one of the schemas it is composed of with allOf declares the property.
+func hasProperty(schema *openapi3.Schema, property string, seen map[*openapi3.Schema]bool) bool {
+	if schema == nil || seen[schema] {
+		return false
+	}
+	seen[schema] = true
+	if _, ok := schema.Properties[property]; ok {
+		return true
+	}
+	for _, ref := range schema.AllOf {
+		if hasProperty(ref.Value, property, seen) {
+			return true
+		}
+	}
+	return false
+}
+
+func sortedMappingValues(mapping map[string]string) []string {
+	values := make([]string, 0, len(mapping))
+	for value := range mapping {
+		values = append(values, value)
+	}
+	sort.Strings(values)
+	return values
+}
@@ -0,0 +1,354 @@
+package scan
+
+import (
+	"fmt"
+	"go/ast"
+	"go/constant"
+	"go/token"
+	"go/types"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// enumValue is a constant declared with a named type.
+type enumValue struct {
+	Name        string
+	Value       interface{}
+	Description string
+	// Text is the result of the String method of the type for the constant, if it could be resolved
+	Text string
+}
+
+// getEnumValues returns the constants declared with the named basic type of decl in declaration order. Types
+// encoded as text, e.g. implementing encoding.TextMarshaler, are enumerated by the values of their String
+// method if they can be resolved for every constant, otherwise they have no enum.
+func (p *Parser) getEnumValues(decl *typeDecl) []*enumValue {
+	values, ok := p.enumValues[decl]
+	if !ok {
+		values = p.resolveEnumValues(decl)
+		p.enumValues[decl] = values
+	}
+	return values
+}
+
+func (p *Parser) resolveEnumValues(decl *typeDecl) []*enumValue {
+	if decl.pkg == nil || decl.pkg.TypesInfo == nil {
+		return nil
+	}
+	obj, ok := decl.pkg.TypesInfo.Defs[decl.spec.Name].(*types.TypeName)
+	if !ok {
+		return nil
+	}
+	if _, ok = obj.Type().Underlying().(*types.Basic); !ok {
+		return nil
+	}
+
+	texts := getStringValues(decl, obj)
+
+	var values []*enumValue
+	for _, file := range decl.pkg.Syntax {
+		for _, d := range file.Decls {
+			gd, ok := d.(*ast.GenDecl)
+			if !ok || gd.Tok != token.CONST {
+				continue
+			}
+			for _, spec := range gd.Specs {
+				vs, ok := spec.(*ast.ValueSpec)
+				if !ok {
+					continue
+				}
+				for _, name := range vs.Names {
+					c, ok := decl.pkg.TypesInfo.Defs[name].(*types.Const)
+					if !ok || name.Name == "_" || !types.Identical(c.Type(), obj.Type()) {
+						continue
+					}
+					doc := vs.Doc
+					if doc == nil && len(gd.Specs) == 1 {
+						doc = gd.Doc
+					}
+					if doc == nil {
+						doc = vs.Comment
+					}
+					values = append(values, &enumValue{
+						Name:        name.Name,
+						Value:       getConstantValue(c.Val()),
+						Description: trimIdentifier(getDescription(doc), name.Name),
+						Text:        texts[c.Val().ExactString()],
+					})
+				}
+			}
+		}
+	}
+
+	if wire := p.getWireSchema(decl); wire != nil && len(values) > 0 {
+		if wire.Type != "string" {
+			return nil
+		}
+		for _, value := range values {
+			if len(value.Text) == 0 {
+				p.report(&Diagnostic{
+					Severity:   SeverityWarning,
+					Message:    fmt.Sprintf("String value of %s could not be resolved, %s is documented without enum", value.Name, decl.spec.Name.Name),
+					Suggestion: "return a string literal for each constant in a switch on the receiver, or index a literal with the receiver",
+				}, decl.spec.Pos())
+				return nil
+			}
+			value.Value = value.Text
+		}
+	}
+	return values
+}
+
+// createEnumSchema creates the component schema name for the named basic type of decl with the enum values.
+func (p *Parser) createEnumSchema(name string, decl *typeDecl, values []*enumValue) *openapi3.Schema {
+	if schemaRef, ok := p.spec.Components.Schemas[name]; ok {
+		return schemaRef.Value
+	}
+
+	var schema openapi3.Schema
+	if wire := p.getWireSchema(decl); wire != nil {
+		// Text encoded constants are enumerated by their String values, see getEnumValues
+		schema = *wire
+	} else {
+		schemaRef := p.ParseTypeExpr(name, decl.spec.Type)
+		if schemaRef == nil || schemaRef.Value == nil {
+			p.warn(decl.spec.Pos(), "", "unsupported type for enum %s", name)
+			return nil
+		}
+		// Copy the schema as the underlying type may be a shared component
+		schema = *schemaRef.Value
+	}
+
+	varNames := make([]interface{}, 0, len(values))
+	descriptions := make([]interface{}, 0, len(values))
+	var hasDescription bool
+	for _, value := range values {
+		schema.Enum = append(schema.Enum, value.Value)
+		varNames = append(varNames, value.Name)
+		descriptions = append(descriptions, value.Description)
+		hasDescription = hasDescription || len(value.Description) > 0
+	}
+
+	schema.Extensions = map[string]interface{}{"x-enum-varnames": varNames}
+	if hasDescription {
+		schema.Extensions["x-enum-descriptions"] = descriptions
+	}
+
+	p.applyFragment(name, parseStructComment(decl.spec.Name.Name, decl.doc).Fragment, &schema)
+
+	p.logger.Debug("found %d enum values for %s", len(values), name)
+	p.schemaMap[name] = &schema
+	p.spec.Components.Schemas[name] = &openapi3.SchemaRef{Value: &schema}
+	return &schema
+}
+
+// getConstantValue converts a constant to the value used in the spec.
+func getConstantValue(value constant.Value) interface{} {
+	switch value.Kind() {
+	case constant.String:
+		return constant.StringVal(value)
+	case constant.Bool:
+		return constant.BoolVal(value)
+	case constant.Int:
+		if v, ok := constant.Int64Val(value); ok {
+			return v
+		}
+		if v, ok := constant.Uint64Val(value); ok {
+			return v
+		}
+	case constant.Float:
+		if v, ok := constant.Float64Val(value); ok {
+			return v
+		}
+	}
+	return value.ExactString()
+}
+
+// getDescription returns the text of a comment group without the openapi annotations.
+func getDescription(cg *ast.CommentGroup) string {
+	if cg == nil {
+		return ""
+	}
+	var lines []string
+	var inDirective bool
+	commented := commentLines(cg)
+	for i, line := range commented {
+		text := strings.TrimSpace(line.text)
+		if strings.HasPrefix(text, annotationPrefix) || inDirective && len(text) > 0 && isContinuation(line.text) ||
+			inDirective && len(text) == 0 && continuesAfterBlank(commented[i+1:]) {
+			// Directives and their continuation lines are not part of the description
+			inDirective = true
+			continue
+		}
+		inDirective = false
+		if len(text) > 0 {
+			lines = append(lines, text)
+		}
+	}
+	return strings.Join(lines, " ")
+}
+
+// declOf returns the declaration of ts from the symbol table.
+func (p *Parser) declOf(ts *ast.TypeSpec) *typeDecl {
+	pkg := p.packageOf(ts.Name.Pos())
+	if pkg == nil || pkg.TypesInfo == nil {
+		return nil
+	}
+	obj, ok := pkg.TypesInfo.Defs[ts.Name].(*types.TypeName)
+	if !ok {
+		return nil
+	}
+	return p.lookupTypeDecl(obj)
+}
+
+// trimIdentifier removes the constant name and a following is from the start of its description, e.g.
+// `StatusActive is active` becomes `Active`.
+func trimIdentifier(description, name string) string {
+	rest, ok := strings.CutPrefix(description, name)
+	if !ok || len(rest) > 0 && !isSpace(rest[0]) {
+		return description
+	}
+	rest = strings.TrimSpace(rest)
+	if after, ok := strings.CutPrefix(rest, "is "); ok {
+		rest = strings.TrimSpace(after)
+	}
+	if len(rest) == 0 {
+		return ""
+	}
+	return exported(rest)
+}
+
+// getStringValues returns the results of the String method of the named type obj by the exact value of the
+// constants. Methods returning a string literal for each constant in a switch on the receiver, or indexing an
+// array, slice or map literal with the receiver, are resolved.
+func getStringValues(decl *typeDecl, obj *types.TypeName) map[string]string {
+	fn := findMethod(decl, obj, "String")
+	if fn == nil || fn.Body == nil || len(fn.Recv.List[0].Names) == 0 {
+		return nil
+	}
+	info := decl.pkg.TypesInfo
+	recv := info.Defs[fn.Recv.List[0].Names[0]]
+	isRecv := func(expr ast.Expr) bool {
+		ident, ok := ast.Unparen(expr).(*ast.Ident)
+		return ok && recv != nil && info.Uses[ident] == recv
+	}
+
+	texts := map[string]string{}
+	for _, stmt := range fn.Body.List {
+		switch s := stmt.(type) {
+		case *ast.SwitchStmt:
+			if s.Tag == nil || !isRecv(s.Tag) {
+				continue
+			}
+			for _, clause := range s.Body.List {
+				cc := clause.(*ast.CaseClause)
+				if len(cc.Body) != 1 {
+					continue
+				}
+				ret, ok := cc.Body[0].(*ast.ReturnStmt)
+				if !ok || len(ret.Results) != 1 {
+					continue
+				}
+				text, ok := stringValue(info, ret.Results[0])
+				if !ok {
+					continue
+				}
+				for _, expr := range cc.List {
+					if tv, ok := info.Types[expr]; ok && tv.Value != nil {
+						texts[tv.Value.ExactString()] = text
+					}
+				}
+			}
+		case *ast.ReturnStmt:
+			if len(s.Results) != 1 {
+				continue
+			}
+			index, ok := ast.Unparen(s.Results[0]).(*ast.IndexExpr)
+			if !ok || !isRecv(index.Index) {
+				continue
+			}
+			lit := compositeLit(decl, index.X)
+			if lit == nil {
+				continue
+			}
+			for i, elt := range lit.Elts {
+				key := constant.MakeInt64(int64(i)).ExactString()
+				if kv, ok := elt.(*ast.KeyValueExpr); ok {
+					tv, ok := info.Types[kv.Key]
+					if !ok || tv.Value == nil {
+						continue
+					}
+					key, elt = tv.Value.ExactString(), kv.Value
+				}
+				if text, ok := stringValue(info, elt); ok {
+					texts[key] = text
+				}
+			}
+		}
+	}
+	return texts
+}
+
+// findMethod returns the declaration of the method name of the named type obj declared in the package of decl.
+func findMethod(decl *typeDecl, obj *types.TypeName, name string) *ast.FuncDecl {
+	for _, file := range decl.pkg.Syntax {
+		for _, d := range file.Decls {
+			fn, ok := d.(*ast.FuncDecl)
+			if !ok || fn.Recv == nil || len(fn.Recv.List) != 1 || fn.Name.Name != name {
+				continue
+			}
+			recv := decl.pkg.TypesInfo.TypeOf(fn.Recv.List[0].Type)
+			if ptr, ok := recv.(*types.Pointer); ok {
+				recv = ptr.Elem()
+			}
+			if named, ok := recv.(*types.Named); ok && named.Obj() == obj {
+				return fn
+			}
+		}
+	}
+	return nil
+}
+
+// compositeLit returns the literal of expr, or of the package level variable named by expr.
+func compositeLit(decl *typeDecl, expr ast.Expr) *ast.CompositeLit {
+	expr = ast.Unparen(expr)
+	if lit, ok := expr.(*ast.CompositeLit); ok {
+		return lit
+	}
+	ident, ok := expr.(*ast.Ident)
+	if !ok {
+		return nil
+	}
+	v, ok := decl.pkg.TypesInfo.Uses[ident].(*types.Var)
+	if !ok {
+		return nil
+	}
+	for _, file := range decl.pkg.Syntax {
+		for _, d := range file.Decls {
+			gd, ok := d.(*ast.GenDecl)
+			if !ok || gd.Tok != token.VAR {
+				continue
+			}
+			for _, spec := range gd.Specs {
+				vs := spec.(*ast.ValueSpec)
+				for i, name := range vs.Names {
+					if decl.pkg.TypesInfo.Defs[name] == v && i < len(vs.Values) {
+						lit, _ := vs.Values[i].(*ast.CompositeLit)
+						return lit
+					}
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// stringValue returns the value of the constant string expression expr.
+func stringValue(info *types.Info, expr ast.Expr) (string, bool) {
+	tv, ok := info.Types[expr]
+	if !ok || tv.Value == nil || tv.Value.Kind() != constant.String {
+		return "", false
+	}
+	return constant.StringVal(tv.Value), true
+}
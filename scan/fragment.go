@@ -0,0 +1,175 @@
+package scan
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"gopkg.in/yaml.v3"
+)
+
+// applyFragment deep-merges the OpenAPI fragment of the openapi:yaml block d into target, a *openapi3.Schema
+// or *openapi3.Operation. Maps are merged, any other value of the fragment replaces the generated value.
+// Only the keywords of the fragment are replaced, so the rest of target keeps its references.
+func (p *Parser) applyFragment(key string, d *directive, target interface{}) {
+	if d == nil {
+		return
+	}
+	if d.Err != nil {
+		p.report(&Diagnostic{
+			Severity:   SeverityError,
+			Directive:  "openapi:yaml",
+			Message:    fmt.Sprintf("fragment of %s is skipped: %s", key, d.Err),
+			Suggestion: "close the block with openapi:yaml end",
+		}, d.Pos)
+		return
+	}
+
+	fragment, err := parseFragment(d.Lines)
+	if err != nil {
+		p.fail(d.Pos, "openapi:yaml", "invalid fragment of %s: %s", key, err)
+		return
+	}
+	if err = mergeFragment(target, fragment); err != nil {
+		p.fail(d.Pos, "openapi:yaml", "invalid fragment of %s: %s", key, err)
+		return
+	}
+	p.logger.Debug("merged fragment into %s", key)
+}
+
+// applySchemaFragment applies the openapi:yaml block d of a field to its schema. References are wrapped in
+// allOf, so the fragment does not modify the referenced component.
+func (p *Parser) applySchemaFragment(key string, d *directive, schemaRef *openapi3.SchemaRef) *openapi3.SchemaRef {
+	if d == nil || schemaRef == nil {
+		return schemaRef
+	}
+	if len(schemaRef.Ref) > 0 {
+		schemaRef = openapi3.NewSchemaRef("", &openapi3.Schema{AllOf: openapi3.SchemaRefs{schemaRef}})
+	}
+	p.applyFragment(key, d, schemaRef.Value)
+	return schemaRef
+}
+
+// parseFragment parses the lines of a YAML or JSON fragment. The lines are dedented and leading tabs, which
+// gofmt uses to indent doc comments, are expanded.
+func parseFragment(lines []string) (map[string]interface{}, error) {
+	expanded := make([]string, 0, len(lines))
+	indent := -1
+	for _, line := range lines {
+		trimmed := strings.TrimLeft(line, " \t")
+		leading := strings.ReplaceAll(line[:len(line)-len(trimmed)], "\t", "  ")
+		if len(trimmed) > 0 && (indent < 0 || len(leading) < indent) {
+			indent = len(leading)
+		}
+		expanded = append(expanded, leading+trimmed)
+	}
+	indent = max(indent, 0)
+	for i, line := range expanded {
+		// Blank lines may be shorter than the indentation
+		expanded[i] = line[min(indent, len(line)-len(strings.TrimLeft(line, " "))):]
+	}
+
+	var fragment interface{}
+	if err := yaml.Unmarshal([]byte(strings.Join(expanded, "\n")), &fragment); err != nil {
+		return nil, err
+	}
+	m, ok := normalizeYAML(fragment).(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("expected a mapping")
+	}
+	return m, nil
+}
+
+// normalizeYAML converts the mappings decoded by yaml, which may have non-string keys like status codes,
+// to the maps decoded by encoding/json.
+func normalizeYAML(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, item := range v {
+			v[key] = normalizeYAML(item)
+		}
+		return v
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			m[fmt.Sprint(key)] = normalizeYAML(item)
+		}
+		return m
+	case []interface{}:
+		for i, item := range v {
+			v[i] = normalizeYAML(item)
+		}
+	}
+	return value
+}
+
+// mergeFragment merges fragment into the JSON encoding of target and replaces the fields of target named
+// by the keys of the fragment with the decoded result.
+func mergeFragment(target interface{}, fragment map[string]interface{}) error {
+	data, err := json.Marshal(target)
+	if err != nil {
+		return err
+	}
+	document := map[string]interface{}{}
+	if err = json.Unmarshal(data, &document); err != nil {
+		return err
+	}
+	mergeMaps(document, fragment)
+	if data, err = json.Marshal(document); err != nil {
+		return err
+	}
+
+	value := reflect.ValueOf(target).Elem()
+	merged := reflect.New(value.Type())
+	if err = json.Unmarshal(data, merged.Interface()); err != nil {
+		return err
+	}
+
+	keys := make([]string, 0, len(fragment))
+	for key := range fragment {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if field, ok := fieldByJSONName(value.Type(), key); ok {
+			value.FieldByIndex(field.Index).Set(merged.Elem().FieldByIndex(field.Index))
+			continue
+		}
+		if !strings.HasPrefix(key, "x-") {
+			return fmt.Errorf("unknown keyword %s", key)
+		}
+		extensions := value.FieldByName("Extensions")
+		if extensions.IsNil() {
+			extensions.Set(reflect.ValueOf(map[string]interface{}{}))
+		}
+		extensions.SetMapIndex(reflect.ValueOf(key), reflect.ValueOf(fragment[key]))
+	}
+	return nil
+}
+
+// mergeMaps deep-merges src into dst.
+func mergeMaps(dst, src map[string]interface{}) {
+	for key, value := range src {
+		if from, ok := value.(map[string]interface{}); ok {
+			if to, ok := dst[key].(map[string]interface{}); ok {
+				mergeMaps(to, from)
+				continue
+			}
+		}
+		dst[key] = value
+	}
+}
+
+// fieldByJSONName returns the field of the struct type t encoded with the JSON name.
+func fieldByJSONName(t reflect.Type, name string) (reflect.StructField, bool) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if tag, _, _ := strings.Cut(field.Tag.Get("json"), ","); tag == name {
+			return field, true
+		}
+	}
+	return reflect.StructField{}, false
+}
@@ -0,0 +1,80 @@
+package scan
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+func TestParseFragment(t *testing.T) {
+	tests := []struct {
+		name    string
+		lines   []string
+		want    map[string]interface{}
+		wantErr bool
+	}{
+		{
+			name:  "tabs",
+			lines: []string{"\tdeprecated: true", "\texternalDocs:", "\t\turl: https://example.com"},
+			want: map[string]interface{}{
+				"deprecated":   true,
+				"externalDocs": map[string]interface{}{"url": "https://example.com"},
+			},
+		},
+		{
+			name:  "blank lines and status codes",
+			lines: []string{"", "  responses:", "", "    404:", "      description: Not found"},
+			want: map[string]interface{}{
+				"responses": map[string]interface{}{"404": map[string]interface{}{"description": "Not found"}},
+			},
+		},
+		{
+			name:  "json",
+			lines: []string{`{"x-order": [1, 2]}`},
+			want:  map[string]interface{}{"x-order": []interface{}{1, 2}},
+		},
+		{
+			name:    "not a mapping",
+			lines:   []string{"- a", "- b"},
+			wantErr: true,
+		},
+		{
+			name:    "invalid yaml",
+			lines:   []string{"a: [b"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseFragment(tt.lines)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseFragment() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseFragment() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMergeFragment(t *testing.T) {
+	items := openapi3.NewSchemaRef("#/components/schemas/Pet", nil)
+	schema := &openapi3.Schema{Type: "array", Items: items, Description: "Pets"}
+
+	err := mergeFragment(schema, map[string]interface{}{"description": "All pets", "x-order": 1})
+	if err != nil {
+		t.Fatalf("mergeFragment() error = %v", err)
+	}
+	if schema.Description != "All pets" || schema.Extensions["x-order"] != 1 {
+		t.Errorf("schema = %+v, want the fragment merged", schema)
+	}
+	if schema.Items != items {
+		t.Errorf("items = %+v, want the generated reference", schema.Items)
+	}
+
+	if err = mergeFragment(schema, map[string]interface{}{"unknown": true}); err == nil {
+		t.Errorf("mergeFragment() error = nil, want unknown keyword")
+	}
+}
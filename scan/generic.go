@@ -0,0 +1,273 @@
+package scan
+
+import (
+	"fmt"
+	"go/ast"
+	"go/types"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// GenericNaming is the naming scheme for the schemas of instantiated generic types.
+type GenericNaming string
+
+const (
+	// GenericNamingConcat appends the type arguments to the type name, e.g. `PagePet`.
+	GenericNamingConcat GenericNaming = "concat"
+	// GenericNamingUnderscore joins the type name and arguments with underscores, e.g. `Page_Pet`.
+	GenericNamingUnderscore GenericNaming = "underscore"
+	// GenericNamingOf joins the type name and arguments with `Of` and `And`, e.g. `PageOfPet`.
+	GenericNamingOf GenericNaming = "of"
+)
+
+// WithGenericNaming sets the naming scheme for instantiated generic types.
+func (p *Parser) WithGenericNaming(naming GenericNaming) *Parser {
+	switch naming {
+	case GenericNamingConcat, GenericNamingUnderscore, GenericNamingOf:
+		p.genericNaming = naming
+	default:
+		p.logger.Warn("unsupported generic naming %s, using %s", naming, GenericNamingConcat)
+		p.genericNaming = GenericNamingConcat
+	}
+	return p
+}
+
+// parseGenericType returns the OpenAPI schema for the generic type expr instantiated with args.
+// Structs are instantiated as components named after the type arguments, any other type is inlined.
+func (p *Parser) parseGenericType(key string, expr ast.Expr, args []ast.Expr) *openapi3.SchemaRef {
+	decl := p.lookupTypeDecl(p.objectOf(expr))
+	if decl == nil {
+		p.logger.Debug("declaration not found for generic type %s", types.ExprString(expr))
+		return nil
+	}
+
+	params := getTypeParams(decl)
+	if len(params) != len(args) {
+		p.warn(expr.Pos(), "", "generic type %s expects %d type arguments, got %d", decl.spec.Name.Name, len(params), len(args))
+		return nil
+	}
+
+	// Bind the type parameters to the arguments while the declaration is parsed
+	restore := p.bindTypeParams(params, args)
+	defer restore()
+
+	if _, ok := decl.spec.Type.(*ast.StructType); !ok {
+		return p.ParseTypeExpr(key, decl.spec.Type)
+	}
+
+	sc := parseStructComment(decl.spec.Name.Name, decl.doc)
+	name := p.getInstanceName(decl, sc.Name, args)
+
+	if _, ok := p.structComments[name]; !ok {
+		p.logger.Debug("instantiating %s as %s", decl.qualifiedName(), name)
+		p.structComments[name] = &structComment{Schema: true, Name: name, XML: sc.XML, Description: sc.Description, Discriminator: sc.Discriminator,
+			Fragment: sc.Fragment}
+	}
+	return &openapi3.SchemaRef{
+		Ref:   fmt.Sprintf("#/components/schemas/%s", name),
+		Value: p.createOpenAPISchema(name, decl.spec),
+	}
+}
+
+// getInstanceName returns the schema name of decl instantiated with args. The type arguments are qualified with
+// their package if the name is already taken by another instantiation, e.g. `PageItem` and `PageBItem`.
+func (p *Parser) getInstanceName(decl *typeDecl, name string, args []ast.Expr) string {
+	ids := make([]string, 0, len(args))
+	for _, arg := range args {
+		ids = append(ids, p.getTypeArgID(arg))
+	}
+	id := decl.qualifiedName() + "[" + strings.Join(ids, ",") + "]"
+	if instance, ok := p.instances[id]; ok {
+		return instance
+	}
+
+	instance := p.getGenericName(name, p.getTypeArgNames(args, false))
+	if other, ok := p.instanceNames[instance]; ok {
+		instance = p.getGenericName(name, p.getTypeArgNames(args, true))
+		if _, ok := p.instanceNames[instance]; ok {
+			p.report(&Diagnostic{
+				Severity:  SeverityError,
+				Directive: "openapi:schema",
+				Message: fmt.Sprintf("schema name %s of %s is already used by %s, the schemas overwrite each other",
+					instance, id, other),
+				Suggestion: "rename one of the type arguments",
+			}, decl.spec.Pos())
+		}
+	}
+	p.instances[id] = instance
+	p.instanceNames[instance] = id
+	return instance
+}
+
+// getTypeArgNames returns the names of args used in the schema name of an instantiated type.
+func (p *Parser) getTypeArgNames(args []ast.Expr, qualified bool) []string {
+	names := make([]string, 0, len(args))
+	for _, arg := range args {
+		names = append(names, p.getTypeArgName(arg, qualified))
+	}
+	return names
+}
+
+// getTypeArgID returns the type argument expr with bound type parameters resolved and named types qualified
+// with their package path, identifying the instantiation. Pointers share the schema of their element type.
+func (p *Parser) getTypeArgID(expr ast.Expr) string {
+	switch t := expr.(type) {
+	case *ast.StarExpr:
+		return p.getTypeArgID(t.X)
+	case *ast.ArrayType:
+		return "[]" + p.getTypeArgID(t.Elt)
+	case *ast.MapType:
+		return "map[" + p.getTypeArgID(t.Key) + "]" + p.getTypeArgID(t.Value)
+	case *ast.IndexExpr:
+		return p.getTypeArgID(t.X) + "[" + p.getTypeArgID(t.Index) + "]"
+	case *ast.IndexListExpr:
+		ids := make([]string, 0, len(t.Indices))
+		for _, index := range t.Indices {
+			ids = append(ids, p.getTypeArgID(index))
+		}
+		return p.getTypeArgID(t.X) + "[" + strings.Join(ids, ",") + "]"
+	case *ast.Ident, *ast.SelectorExpr:
+		if obj := p.objectOf(t); obj != nil {
+			if arg, ok := p.typeArgs[obj]; ok && isIdent(t) {
+				return p.getTypeArgID(arg)
+			}
+			if obj.Pkg() != nil {
+				return obj.Pkg().Path() + "." + obj.Name()
+			}
+			return obj.Name()
+		}
+	}
+	return types.ExprString(expr)
+}
+
+// qualifyTypeArgName prefixes name with the package name of the type expr if qualified is set.
+func (p *Parser) qualifyTypeArgName(expr ast.Expr, name string, qualified bool) string {
+	if !qualified {
+		return name
+	}
+	if obj := p.objectOf(expr); obj != nil && obj.Pkg() != nil {
+		return exported(obj.Pkg().Name()) + name
+	}
+	return name
+}
+
+// parseTypeParam returns the OpenAPI schema for the argument bound to the type parameter obj.
+func (p *Parser) parseTypeParam(key string, obj *types.TypeName) *openapi3.SchemaRef {
+	arg, ok := p.typeArgs[obj]
+	if !ok {
+		p.warn(obj.Pos(), "", "type parameter %s of %s is not instantiated", obj.Name(), key)
+		return nil
+	}
+	return p.ParseTypeExpr(key, arg)
+}
+
+// bindTypeParams binds params to args and returns a function restoring the previous bindings.
+func (p *Parser) bindTypeParams(params []*types.TypeName, args []ast.Expr) func() {
+	// Arguments referring to bound type parameters are resolved before any parameter is rebound
+	resolved := make([]ast.Expr, len(args))
+	for i, arg := range args {
+		resolved[i] = arg
+		if obj := p.objectOf(arg); obj != nil {
+			if bound, ok := p.typeArgs[obj]; ok && isIdent(arg) {
+				resolved[i] = bound
+			}
+		}
+	}
+
+	previous := map[*types.TypeName]ast.Expr{}
+	for i, param := range params {
+		if arg, ok := p.typeArgs[param]; ok {
+			previous[param] = arg
+		}
+		p.typeArgs[param] = resolved[i]
+	}
+
+	return func() {
+		for _, param := range params {
+			if arg, ok := previous[param]; ok {
+				p.typeArgs[param] = arg
+			} else {
+				delete(p.typeArgs, param)
+			}
+		}
+	}
+}
+
+// getTypeArgs returns the type arguments of the instantiated generic type expr, e.g. `Pet` for `*Page[Pet]`.
+func getTypeArgs(expr ast.Expr) []ast.Expr {
+	if star, ok := expr.(*ast.StarExpr); ok {
+		expr = star.X
+	}
+	switch t := expr.(type) {
+	case *ast.IndexExpr:
+		return []ast.Expr{t.Index}
+	case *ast.IndexListExpr:
+		return t.Indices
+	}
+	return nil
+}
+
+// getTypeParams returns the type parameters declared by decl.
+func getTypeParams(decl *typeDecl) []*types.TypeName {
+	var params []*types.TypeName
+	if decl.spec.TypeParams == nil || decl.pkg == nil || decl.pkg.TypesInfo == nil {
+		return params
+	}
+	for _, field := range decl.spec.TypeParams.List {
+		for _, name := range field.Names {
+			if obj, ok := decl.pkg.TypesInfo.Defs[name].(*types.TypeName); ok {
+				params = append(params, obj)
+			}
+		}
+	}
+	return params
+}
+
+// getTypeArgName returns the name of a type argument used in the schema name of an instantiated type.
+// Named types are prefixed with their package name if qualified is set, e.g. `PetsPet`.
+func (p *Parser) getTypeArgName(expr ast.Expr, qualified bool) string {
+	switch t := expr.(type) {
+	case *ast.StarExpr:
+		return p.getTypeArgName(t.X, qualified)
+	case *ast.ArrayType:
+		return p.getTypeArgName(t.Elt, qualified) + "List"
+	case *ast.MapType:
+		return p.getTypeArgName(t.Value, qualified) + "Map"
+	case *ast.SelectorExpr:
+		return p.qualifyTypeArgName(t, t.Sel.Name, qualified)
+	case *ast.IndexExpr:
+		return p.getGenericName(p.getTypeArgName(t.X, qualified), []string{p.getTypeArgName(t.Index, qualified)})
+	case *ast.IndexListExpr:
+		names := make([]string, 0, len(t.Indices))
+		for _, index := range t.Indices {
+			names = append(names, p.getTypeArgName(index, qualified))
+		}
+		return p.getGenericName(p.getTypeArgName(t.X, qualified), names)
+	case *ast.Ident:
+		if obj := p.objectOf(t); obj != nil {
+			if arg, ok := p.typeArgs[obj]; ok {
+				return p.getTypeArgName(arg, qualified)
+			}
+		}
+		return p.qualifyTypeArgName(t, exported(t.Name), qualified)
+	}
+	return "Any"
+}
+
+// getGenericName returns the schema name of the generic type name instantiated with args.
+func (p *Parser) getGenericName(name string, args []string) string {
+	switch p.genericNaming {
+	case GenericNamingUnderscore:
+		return name + "_" + strings.Join(args, "_")
+	case GenericNamingOf:
+		return name + "Of" + strings.Join(args, "And")
+	default:
+		return name + strings.Join(args, "")
+	}
+}
+
+func isIdent(expr ast.Expr) bool {
+	_, ok := expr.(*ast.Ident)
+	return ok
+}
@@ -0,0 +1,87 @@
+package scan
+
+import (
+	"fmt"
+	"go/types"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// openAPITypes are the schema types accepted by the openapi:type annotation.
+var openAPITypes = map[string]bool{
+	"string": true, "integer": true, "number": true, "boolean": true, "array": true, "object": true,
+}
+
+// parseTypeAnnotation parses the `openapi:type <type> [format]` annotation.
+func parseTypeAnnotation(text string) (*openapi3.Schema, error) {
+	d := parseArguments(text)
+	if d.Err != nil {
+		return nil, d.Err
+	}
+	fields := d.Args
+	if len(fields) == 0 || len(fields) > 2 {
+		return nil, fmt.Errorf("expected <type> [format], got %q", text)
+	}
+	if !openAPITypes[fields[0]] {
+		return nil, fmt.Errorf("unsupported type %s", fields[0])
+	}
+
+	schema := &openapi3.Schema{Type: fields[0]}
+	if len(fields) == 2 {
+		schema.Format = fields[1]
+	}
+	return schema, nil
+}
+
+// getWireSchema returns the schema of decl as it is serialized by encoding/json if it differs from the
+// declaration, or nil otherwise. The openapi:type annotation takes precedence over the JSON and text
+// marshalers implemented by the type. Types with a custom JSON encoding cannot be inferred and allow any value.
+func (p *Parser) getWireSchema(decl *typeDecl) *openapi3.Schema {
+	schema, ok := p.wireSchemas[decl]
+	if !ok {
+		schema = p.resolveWireSchema(decl)
+		p.wireSchemas[decl] = schema
+	}
+	if schema == nil {
+		return nil
+	}
+	wire := *schema
+	return &wire
+}
+
+func (p *Parser) resolveWireSchema(decl *typeDecl) *openapi3.Schema {
+	if d := findDirective(decl.doc, "type"); d != nil {
+		schema, err := parseTypeAnnotation(d.Text)
+		if err == nil {
+			return schema
+		}
+		p.warn(d.Pos, "openapi:type", "invalid value for %s: %s", decl.spec.Name.Name, err)
+	}
+
+	if decl.pkg == nil || decl.pkg.TypesInfo == nil {
+		return nil
+	}
+	tn, ok := decl.pkg.TypesInfo.Defs[decl.spec.Name].(*types.TypeName)
+	if !ok {
+		return nil
+	}
+
+	switch {
+	case hasMethod(tn.Type(), "MarshalJSON") || hasMethod(tn.Type(), "UnmarshalJSON"):
+		p.report(&Diagnostic{
+			Severity:   SeverityWarning,
+			Message:    fmt.Sprintf("%s implements a custom JSON encoding and is documented as any value", decl.spec.Name.Name),
+			Suggestion: "annotate it with openapi:type",
+		}, decl.spec.Pos())
+		return &openapi3.Schema{}
+	case hasMethod(tn.Type(), "MarshalText") || hasMethod(tn.Type(), "UnmarshalText"):
+		p.logger.Debug("%s implements encoding.TextMarshaler", decl.qualifiedName())
+		return &openapi3.Schema{Type: "string"}
+	}
+	return nil
+}
+
+// hasMethod reports whether t or a pointer to t has the exported method name, including promoted methods.
+func hasMethod(t types.Type, name string) bool {
+	return types.NewMethodSet(types.NewPointer(t)).Lookup(nil, name) != nil
+}
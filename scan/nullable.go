@@ -0,0 +1,285 @@
+package scan
+
+import (
+	"encoding/json"
+	"go/ast"
+	"go/types"
+	"sort"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"gopkg.in/yaml.v3"
+)
+
+// NullablePolicy decides which fields are nullable when they are not annotated with openapi:nullable.
+type NullablePolicy string
+
+const (
+	// NullablePolicyExplicit makes only annotated fields nullable.
+	NullablePolicyExplicit NullablePolicy = "explicit"
+	// NullablePolicyPointer additionally makes pointer fields nullable.
+	NullablePolicyPointer NullablePolicy = "pointer"
+	// NullablePolicyNil additionally makes every field nullable that encodes nil as null, i.e. pointers, slices, maps and interfaces.
+	NullablePolicyNil NullablePolicy = "nil"
+)
+
+// WithNullablePolicy sets the policy used to infer the nullable fields.
+func (p *Parser) WithNullablePolicy(policy NullablePolicy) *Parser {
+	switch policy {
+	case NullablePolicyExplicit, NullablePolicyPointer, NullablePolicyNil:
+		p.nullablePolicy = policy
+	default:
+		p.logger.Warn("unsupported nullable policy %s, using %s", policy, NullablePolicyExplicit)
+		p.nullablePolicy = NullablePolicyExplicit
+	}
+	return p
+}
+
+// WithOpenAPIVersion sets the OpenAPI version of the spec. Nullable schemas of 3.0 specs are marked
+// with `nullable`, 3.1 specs add the `null` type instead, see ToDocument.
+func (p *Parser) WithOpenAPIVersion(version string) *Parser {
+	if !strings.HasPrefix(version, "3.0.") && !strings.HasPrefix(version, "3.1.") {
+		p.logger.Warn("unsupported OpenAPI version %s, using %s", version, p.spec.OpenAPI)
+		return p
+	}
+	p.spec.OpenAPI = version
+	return p
+}
+
+// isNullable reports whether the field is nullable. The openapi:nullable annotation takes precedence
+// over the nullable policy. Fields with `omitempty` or `omitzero` are omitted instead of being null.
+func (p *Parser) isNullable(fc *fieldComment, field *ast.Field, opts tagOptions) bool {
+	if fc != nil && fc.Nullable != nil {
+		return *fc.Nullable
+	}
+	if opts.Contains("omitempty") || opts.Contains("omitzero") {
+		return false
+	}
+
+	switch p.nullablePolicy {
+	case NullablePolicyPointer:
+		return p.isPointer(field.Type)
+	case NullablePolicyNil:
+		return p.isNil(field.Type)
+	}
+	return false
+}
+
+// isNil reports whether values of expr may be nil.
+func (p *Parser) isNil(expr ast.Expr) bool {
+	if t := p.typeOf(expr); t != nil {
+		switch t.Underlying().(type) {
+		case *types.Pointer, *types.Slice, *types.Map, *types.Interface:
+			return true
+		}
+		return false
+	}
+	switch t := expr.(type) {
+	case *ast.StarExpr, *ast.MapType, *ast.InterfaceType:
+		return true
+	case *ast.ArrayType:
+		return t.Len == nil
+	}
+	return false
+}
+
+// ToDocument returns the spec as a generic document for encoding, including the x- extensions. Nullable
+// schemas of OpenAPI 3.1 documents use the `null` type, e.g. `type: [string, "null"]`, instead of `nullable`.
+func ToDocument(spec *openapi3.T) (map[string]interface{}, error) {
+	data, err := json.Marshal(spec)
+	if err != nil {
+		return nil, err
+	}
+
+	var doc map[string]interface{}
+	if err = json.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+
+	if strings.HasPrefix(spec.OpenAPI, "3.1") {
+		convertSchemas(doc, false)
+	}
+	return doc, nil
+}
+
+// ToYAML encodes the document of spec returned by ToDocument as YAML. The keys are written in the order of the
+// fields of the openapi3 types, e.g. `openapi` first, followed by the keys without a field, e.g. extensions, in
+// alphabetical order.
+func ToYAML(spec *openapi3.T) ([]byte, error) {
+	doc, err := ToDocument(spec)
+	if err != nil {
+		return nil, err
+	}
+
+	var fields yaml.Node
+	if err = fields.Encode(spec); err != nil {
+		return nil, err
+	}
+	node, err := orderedNode(doc, &fields)
+	if err != nil {
+		return nil, err
+	}
+	return yaml.Marshal(node)
+}
+
+// orderedNode returns the YAML node of the document value with the keys of its mappings in the order of the
+// keys of the corresponding mappings of order, if any.
+func orderedNode(value interface{}, order *yaml.Node) (*yaml.Node, error) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		positions := map[string]int{}
+		children := map[string]*yaml.Node{}
+		if order != nil && order.Kind == yaml.MappingNode {
+			for i := 0; i+1 < len(order.Content); i += 2 {
+				positions[order.Content[i].Value] = i / 2
+				children[order.Content[i].Value] = order.Content[i+1]
+			}
+		}
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		position := func(key string) int {
+			if pos, ok := positions[key]; ok {
+				return pos
+			}
+			return len(positions)
+		}
+		sort.Slice(keys, func(i, j int) bool {
+			if pi, pj := position(keys[i]), position(keys[j]); pi != pj {
+				return pi < pj
+			}
+			return keys[i] < keys[j]
+		})
+
+		node := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+		for _, key := range keys {
+			child, err := orderedNode(v[key], children[key])
+			if err != nil {
+				return nil, err
+			}
+			node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key}, child)
+		}
+		return node, nil
+	case []interface{}:
+		node := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+		for i, item := range v {
+			var itemOrder *yaml.Node
+			if order != nil && order.Kind == yaml.SequenceNode && i < len(order.Content) {
+				itemOrder = order.Content[i]
+			}
+			child, err := orderedNode(item, itemOrder)
+			if err != nil {
+				return nil, err
+			}
+			node.Content = append(node.Content, child)
+		}
+		return node, nil
+	}
+
+	var node yaml.Node
+	if err := node.Encode(value); err != nil {
+		return nil, err
+	}
+	return &node, nil
+}
+
+// convertSchemas converts the OpenAPI 3.0 keywords of the schemas of node to JSON Schema 2020-12 as
+// used by OpenAPI 3.1. The keys of names are property or schema names rather than keywords.
+func convertSchemas(node interface{}, names bool) {
+	switch n := node.(type) {
+	case []interface{}:
+		for _, value := range n {
+			convertSchemas(value, false)
+		}
+	case map[string]interface{}:
+		for key, value := range n {
+			if names {
+				convertSchemas(value, false)
+				continue
+			}
+			switch {
+			case key == "example" || key == "examples" || key == "default" || key == "enum" || strings.HasPrefix(key, "x-"):
+				// Values are not schemas
+			case key == "properties" || key == "schemas":
+				convertSchemas(value, true)
+			default:
+				convertSchemas(value, false)
+			}
+		}
+		if names {
+			return
+		}
+		convertExclusiveBound(n, "exclusiveMinimum", "minimum")
+		convertExclusiveBound(n, "exclusiveMaximum", "maximum")
+		convertNullable(n)
+	}
+}
+
+// convertExclusiveBound replaces the boolean exclusive keyword of the schema n, e.g. `exclusiveMinimum: true`,
+// by the numeric keyword holding the bound.
+func convertExclusiveBound(n map[string]interface{}, exclusive, bound string) {
+	isExclusive, ok := n[exclusive].(bool)
+	if !ok {
+		return
+	}
+	delete(n, exclusive)
+	if value, ok := n[bound]; ok && isExclusive {
+		n[exclusive] = value
+		delete(n, bound)
+	}
+}
+
+// convertNullable replaces `nullable` of the schema n by the `null` type.
+func convertNullable(n map[string]interface{}) {
+	nullable, ok := n["nullable"].(bool)
+	if !ok {
+		return
+	}
+	delete(n, "nullable")
+	if !nullable {
+		return
+	}
+
+	if schemaType, ok := n["type"].(string); ok {
+		n["type"] = []interface{}{schemaType, "null"}
+		if enum, ok := n["enum"].([]interface{}); ok {
+			n["enum"] = append(enum, nil)
+		}
+	} else if allOf, ok := n["allOf"].([]interface{}); ok && len(allOf) == 1 {
+		// Nullable references are wrapped in allOf
+		delete(n, "allOf")
+		n["anyOf"] = []interface{}{allOf[0], nullSchema()}
+	} else if oneOf, ok := n["oneOf"].([]interface{}); ok {
+		n["oneOf"] = append(oneOf, nullSchema())
+	} else if anyOf, ok := n["anyOf"].([]interface{}); ok {
+		n["anyOf"] = append(anyOf, nullSchema())
+	} else {
+		wrapNullable(n)
+	}
+}
+
+func nullSchema() map[string]interface{} {
+	return map[string]interface{}{"type": "null"}
+}
+
+// annotationKeywords are the keywords describing a schema rather than constraining its values.
+var annotationKeywords = map[string]bool{
+	"title": true, "description": true, "example": true, "examples": true, "default": true, "deprecated": true,
+	"readOnly": true, "writeOnly": true, "externalDocs": true,
+}
+
+// wrapNullable moves the constraints of the nullable schema n into `anyOf: [<schema>, {type: null}]`.
+// Schemas without constraints accept null already.
+func wrapNullable(n map[string]interface{}) {
+	schema := map[string]interface{}{}
+	for key, value := range n {
+		if !annotationKeywords[key] && !strings.HasPrefix(key, "x-") {
+			schema[key] = value
+			delete(n, key)
+		}
+	}
+	if len(schema) > 0 {
+		n["anyOf"] = []interface{}{schema, nullSchema()}
+	}
+}
@@ -1,10 +1,15 @@
 package scan
 
 import (
+	"errors"
 	"fmt"
 	"github.com/getkin/kin-openapi/openapi3"
 	"go/ast"
+	"go/parser"
+	"go/token"
 	"strings"
+
+	"golang.org/x/tools/go/packages"
 )
 
 type openAPIOperation struct {
@@ -19,6 +24,17 @@ type openAPIOperation struct {
 	RequestBody *RequestBody
 	Responses   []*ResponseBody
 	Parameters  []*Parameter
+	// BasePath prefixes the Path, e.g. /v1
+	BasePath string
+	// Security are the alternative security requirements, an empty list disables the security of the operation
+	Security *openapi3.SecurityRequirements
+
+	// pkg is the package declaring the operation, used to resolve the request and response types
+	pkg *packages.Package
+	// pos is the position of the openapi:operation annotation
+	pos token.Pos
+	// fragment is the openapi:yaml block merged into the operation
+	fragment *directive
 }
 
 type RequestBody struct {
@@ -62,12 +78,17 @@ func (p *Parser) generateOperation(op *openAPIOperation) {
 		resp.Tags = op.Tags
 	}
 
-	resp.RequestBody = getRequestBodyFromOperation(p.schemaMap[op.RequestBody.Name], op)
+	if op.Security != nil {
+		resp.Security = op.Security
+		p.security = append(p.security, &securityCheck{key: op.OperationID, pos: op.pos, requirements: *op.Security})
+	}
+
+	resp.RequestBody = getRequestBodyFromOperation(p.getSchemaByName(op, op.RequestBody.Name), op)
 	resp.Parameters = getParametersFromMethodComments(op.Parameters)
 
 	resp.Responses = make(openapi3.Responses)
 	for _, responseBody := range op.Responses {
-		resp.Responses[responseBody.Code] = getResponseFromOperation(p.schemaMap[responseBody.Name], op, responseBody)
+		resp.Responses[responseBody.Code] = getResponseFromOperation(p.getSchemaByName(op, responseBody.Name), op, responseBody)
 	}
 
 	// Set the op tags.
@@ -75,8 +96,10 @@ func (p *Parser) generateOperation(op *openAPIOperation) {
 		resp.Tags = op.Tags
 	}
 
+	p.applyFragment(op.OperationID, op.fragment, resp)
+
 	// Get or create the path item for the method's path.
-	path := strings.Join([]string{"", op.Path}, "")
+	path := joinPath(op.BasePath, op.Path)
 	pathItem := &openapi3.PathItem{}
 
 	// Add the op to the path item.
@@ -102,13 +125,218 @@ func (p *Parser) generateOperation(op *openAPIOperation) {
 		pathItem.Get = resp
 	default:
 		// If the method name isn't recognized, skip it.
-		p.logger.Warn("unrecognized method setting to %s", op.OperationID)
+		p.warn(op.pos, "openapi:operation", "unsupported method %s of %s, the operation is skipped", op.Method, op.OperationID)
 		return
 	}
 
 	p.spec.AddOperation(path, op.Method, resp)
 }
 
+// getSchemaByName returns the schema for a type named in an operation annotation. The name is either
+// an openapi:schema name or a Go type visible from the package of op, optionally qualified with its package.
+func (p *Parser) getSchemaByName(op *openAPIOperation, name string) *openapi3.Schema {
+	if len(name) == 0 {
+		return nil
+	}
+	if schema, ok := p.schemaMap[name]; ok {
+		return schema
+	}
+
+	if strings.Contains(name, "[") {
+		// Instantiated generic types are parsed as type expressions resolved in the scope of pkg
+		expr, err := parser.ParseExprFrom(p.fileSet, "", name, 0)
+		if err != nil {
+			p.warn(op.pos, "openapi:operation", "invalid type %s of %s: %s", name, op.OperationID, err)
+			return nil
+		}
+		p.pkg = op.pkg
+		schemaRef := p.ParseTypeExpr(name, expr)
+		if schemaRef == nil {
+			p.warn(op.pos, "openapi:operation", "schema %s of %s not found", name, op.OperationID)
+			return nil
+		}
+		return schemaRef.Value
+	}
+
+	obj := p.lookupTypeName(op.pkg, name)
+	if obj == nil {
+		p.warn(op.pos, "openapi:operation", "schema %s of %s not found", name, op.OperationID)
+		return nil
+	}
+	schemaRef := p.parseNamedType(name, obj)
+	if schemaRef == nil {
+		return nil
+	}
+	return schemaRef.Value
+}
+
+// collectOperation adds the operation annotated in cg. The shared directives of the interface or receiver
+// type declaring the method apply unless cg overrides them. Malformed annotations are reported, comment
+// groups without openapi:operation are skipped.
+func (p *Parser) collectOperation(name string, cg *ast.CommentGroup, shared *openAPIOperation) {
+	op, err := extractOpenAPIOperation(name, cg)
+	var annotationErr *annotationError
+	if errors.As(err, &annotationErr) {
+		p.reportAnnotationError(annotationErr)
+		return
+	}
+	if err != nil {
+		p.logger.Debug(err.Error())
+		return
+	}
+	op.inherit(shared)
+	op.pkg = p.pkg
+	p.operations = append(p.operations, op)
+}
+
+// sharedOperation returns the inherited directives of the doc comment cg of the interface or receiver type
+// name. Comment groups are parsed once, so malformed directives are reported once for all methods.
+func (p *Parser) sharedOperation(name string, cg *ast.CommentGroup) *openAPIOperation {
+	if cg == nil {
+		return nil
+	}
+	if shared, ok := p.sharedOperations[cg]; ok {
+		return shared
+	}
+
+	shared := &openAPIOperation{}
+	for _, d := range parseDirectives(cg) {
+		if !inheritedDirectives[d.Name] {
+			continue
+		}
+		err := shared.addDirective(name, d)
+		var annotationErr *annotationError
+		if errors.As(err, &annotationErr) {
+			annotationErr.message += ", the directive is ignored"
+			p.reportAnnotationError(annotationErr)
+		}
+	}
+	p.sharedOperations[cg] = shared
+	return shared
+}
+
+// receiverOperation returns the shared directives of the type declaring the method fn, or nil for functions.
+func (p *Parser) receiverOperation(fn *ast.FuncDecl) *openAPIOperation {
+	if fn.Recv == nil || len(fn.Recv.List) == 0 || p.pkg == nil {
+		return nil
+	}
+	expr := fn.Recv.List[0].Type
+	if star, ok := expr.(*ast.StarExpr); ok {
+		expr = star.X
+	}
+	// Generic receivers are instantiated with their type parameters, e.g. Store[T]
+	switch x := expr.(type) {
+	case *ast.IndexExpr:
+		expr = x.X
+	case *ast.IndexListExpr:
+		expr = x.X
+	}
+	ident, ok := expr.(*ast.Ident)
+	if !ok {
+		return nil
+	}
+	if decl, ok := p.typeDecls[p.pkg.PkgPath+"."+ident.Name]; ok {
+		return p.sharedOperation(ident.Name, decl.doc)
+	}
+	return nil
+}
+
+// inheritedDirectives are the operation directives of an interface or receiver type that apply to each of
+// its methods.
+var inheritedDirectives = map[string]bool{
+	"tag": true, "basePath": true, "security": true, "consumes": true, "produces": true, "param": true,
+	"response": true,
+}
+
+// inherit applies the shared directives that op does not override. Tags, media types, the base path and the
+// security of op replace the shared ones, parameters are overridden by name and location and responses
+// by status code.
+func (op *openAPIOperation) inherit(shared *openAPIOperation) {
+	if shared == nil {
+		return
+	}
+	if len(op.Tags) == 0 {
+		op.Tags = shared.Tags
+	}
+	if len(op.Consumes) == 0 {
+		op.Consumes = shared.Consumes
+	}
+	if len(op.Produces) == 0 {
+		op.Produces = shared.Produces
+	}
+	if len(op.BasePath) == 0 {
+		op.BasePath = shared.BasePath
+	}
+	if op.Security == nil {
+		op.Security = shared.Security
+	}
+
+	var parameters []*Parameter
+	for _, parameter := range shared.Parameters {
+		if !op.hasParameter(parameter.Name, parameter.In) {
+			parameters = append(parameters, parameter)
+		}
+	}
+	op.Parameters = append(parameters, op.Parameters...)
+
+	for _, response := range shared.Responses {
+		if !op.hasResponse(response.Code) {
+			op.Responses = append(op.Responses, response)
+		}
+	}
+}
+
+func (op *openAPIOperation) hasParameter(name, in string) bool {
+	for _, parameter := range op.Parameters {
+		if parameter.Name == name && parameter.In == in {
+			return true
+		}
+	}
+	return false
+}
+
+func (op *openAPIOperation) hasResponse(code string) bool {
+	for _, response := range op.Responses {
+		if response.Code == code {
+			return true
+		}
+	}
+	return false
+}
+
+// joinPath prefixes path with the base path of the operation.
+func joinPath(basePath, path string) string {
+	if len(basePath) == 0 {
+		return path
+	}
+	return strings.TrimSuffix(basePath, "/") + "/" + strings.TrimPrefix(path, "/")
+}
+
+// operationFormats are the formats of the operation annotations with arguments.
+var operationFormats = map[string]string{
+	"operation": "openapi:operation <Method> <Path> <OperationID>",
+	"consumes":  "openapi:consumes <MediaType> ...",
+	"produces":  "openapi:produces <MediaType> ...",
+	"body":      "openapi:body <Object> --- <Description>",
+	"response":  "openapi:response <Code> [Object] [--- Description]",
+	"param":     "openapi:param <Name> <In> <Type> <Required> [--- Description]",
+	"basePath":  "openapi:basePath <Path>",
+	"security":  "openapi:security <Scheme> [Scope] ... | none",
+}
+
+// invalidOperation returns the error for the malformed directive d of the operation name.
+func invalidOperation(name string, d *directive, message string) error {
+	err := &annotationError{
+		pos:       d.Pos,
+		directive: annotationPrefix + d.Name,
+		message:   fmt.Sprintf("%s in %s", message, name),
+	}
+	if format, ok := operationFormats[d.Name]; ok {
+		err.suggestion = "expected " + format
+	}
+	return err
+}
+
 func extractOpenAPIOperation(name string, cg *ast.CommentGroup) (*openAPIOperation, error) {
 	op := &openAPIOperation{
 		Responses:   []*ResponseBody{},
@@ -122,76 +350,15 @@ func extractOpenAPIOperation(name string, cg *ast.CommentGroup) (*openAPIOperati
 
 	var isValidOperation bool
 
-	for _, comment := range cg.List {
-		text := strings.TrimSpace(strings.TrimLeft(comment.Text, "//"))
-
-		if strings.HasPrefix(text, "openapi:operation") {
-			t := strings.TrimSpace(strings.TrimLeft(text, "openapi:operation"))
-			parts := strings.Split(t, " ")
-			if len(parts) != 3 {
-				return nil, fmt.Errorf("invalid openapi:operation format: %s", name)
+	for _, d := range parseDirectives(cg) {
+		if err := op.addDirective(name, d); err != nil {
+			var annotationErr *annotationError
+			if errors.As(err, &annotationErr) {
+				annotationErr.message += ", the operation is skipped"
 			}
-			op.Method = parts[0]
-			op.Path = parts[1]
-			op.OperationID = parts[2]
-			isValidOperation = true
-		} else if strings.HasPrefix(text, "openapi:summary") {
-			op.Summary = strings.TrimSpace(strings.TrimPrefix(text, "openapi:summary"))
-		} else if strings.HasPrefix(text, "openapi:description") {
-			op.Description = strings.TrimSpace(strings.TrimPrefix(text, "openapi:description"))
-		} else if strings.HasPrefix(text, "openapi:tag") {
-			op.Tags = append(op.Tags, strings.TrimSpace(strings.TrimPrefix(text, "openapi:tag")))
-		} else if strings.HasPrefix(text, "openapi:consumes") {
-			parts := strings.Split(strings.TrimSpace(strings.TrimPrefix(text, "openapi:consumes")), " ")
-			op.Consumes = append(op.Consumes, parts...)
-		} else if strings.HasPrefix(text, "openapi:produces") {
-			parts := strings.Split(strings.TrimSpace(strings.TrimPrefix(text, "openapi:produces")), " ")
-			op.Produces = append(op.Produces, parts...)
-		} else if strings.HasPrefix(text, "openapi:body") {
-			parts := strings.Split(strings.TrimSpace(strings.TrimPrefix(text, "openapi:body")), "---")
-			if len(parts) != 2 {
-				return nil, fmt.Errorf("invalid openapi:body format: %s", name)
-			}
-			op.RequestBody.Name = strings.TrimSpace(parts[0])
-			op.RequestBody.Description = strings.TrimSpace(parts[1])
-		} else if strings.HasPrefix(text, "openapi:response") {
-			res := &ResponseBody{}
-			parts := strings.Split(strings.TrimSpace(strings.TrimPrefix(text, "openapi:response")), "---")
-			if len(parts) > 2 {
-				return nil, fmt.Errorf("invalid openapi:response format: %s", name)
-			} else if len(parts) == 2 {
-				res.Description = strings.TrimSpace(parts[1])
-			}
-			parts = strings.Split(strings.TrimSpace(parts[0]), " ")
-			switch len(parts) {
-			case 1:
-				res.Code = parts[0]
-			case 2:
-				res.Code = parts[0]
-				res.Name = parts[1]
-			default:
-				return nil, fmt.Errorf("invalid openapi:response format: %s", name)
-			}
-			op.Responses = append(op.Responses, res)
-		} else if strings.HasPrefix(text, "openapi:param") {
-			p := &Parameter{}
-			parts := strings.Split(strings.TrimSpace(strings.TrimPrefix(text, "openapi:param")), "---")
-
-			if len(parts) == 2 {
-				p.Description = strings.TrimSpace(parts[1])
-			}
-
-			parts = strings.Split(strings.TrimSpace(parts[0]), " ")
-
-			if len(parts) != 4 {
-				return nil, fmt.Errorf("invalid openapi:param format: %s", name)
-			}
-			p.Name = parts[0]
-			p.In = parts[1]
-			p.Type = parts[2]
-			p.Required = parts[3]
-			op.Parameters = append(op.Parameters, p)
+			return nil, err
 		}
+		isValidOperation = isValidOperation || d.Name == "operation"
 	}
 
 	if !isValidOperation {
@@ -200,3 +367,87 @@ func extractOpenAPIOperation(name string, cg *ast.CommentGroup) (*openAPIOperati
 
 	return op, nil
 }
+
+// addDirective adds the directive d of the operation name to op.
+func (op *openAPIOperation) addDirective(name string, d *directive) error {
+	switch d.Name {
+	case "summary", "description", "tag", "yaml":
+		// Free text is not tokenized, e.g. it may contain an unbalanced quote
+	default:
+		if d.Err != nil {
+			return invalidOperation(name, d, d.Err.Error())
+		}
+	}
+
+	switch d.Name {
+	case "operation":
+		if len(d.Args) != 3 {
+			return invalidOperation(name, d, "invalid format")
+		}
+		op.Method = d.Args[0]
+		op.Path = d.Args[1]
+		op.OperationID = d.Args[2]
+		op.pos = d.Pos
+	case "summary":
+		op.Summary = d.value()
+	case "description":
+		op.Description = d.value()
+	case "tag":
+		op.Tags = append(op.Tags, d.value())
+	case "consumes":
+		op.Consumes = append(op.Consumes, d.Args...)
+	case "produces":
+		op.Produces = append(op.Produces, d.Args...)
+	case "body":
+		if len(d.Args) != 1 || len(d.Description) == 0 {
+			return invalidOperation(name, d, "invalid format")
+		}
+		op.RequestBody.Name = d.Args[0]
+		op.RequestBody.Description = d.Description
+	case "response":
+		if len(d.Args) != 1 && len(d.Args) != 2 {
+			return invalidOperation(name, d, "invalid format")
+		}
+		op.Responses = append(op.Responses, &ResponseBody{
+			Code:        d.Args[0],
+			Name:        d.arg(1),
+			Description: d.Description,
+		})
+	case "param":
+		if len(d.Args) != 4 {
+			return invalidOperation(name, d, "invalid format")
+		}
+		op.Parameters = append(op.Parameters, &Parameter{
+			Name:        d.Args[0],
+			In:          d.Args[1],
+			Type:        d.Args[2],
+			Required:    d.Args[3],
+			Description: d.Description,
+		})
+	case "basePath":
+		if len(d.Args) != 1 {
+			return invalidOperation(name, d, "invalid format")
+		}
+		op.BasePath = d.Args[0]
+	case "security":
+		if len(d.Args) == 0 {
+			return invalidOperation(name, d, "invalid format")
+		}
+		// An empty list is set by none
+		if d.Args[0] == "none" && (len(d.Args) != 1 || op.Security != nil && len(*op.Security) > 0) ||
+			d.Args[0] != "none" && op.Security != nil && len(*op.Security) == 0 {
+			return invalidOperation(name, d, "none cannot be combined with other security requirements")
+		}
+		if op.Security == nil {
+			op.Security = &openapi3.SecurityRequirements{}
+		}
+		if d.Args[0] == "none" {
+			break
+		}
+		scopes := append([]string{}, d.Args[1:]...)
+		*op.Security = append(*op.Security, openapi3.SecurityRequirement{d.Args[0]: scopes})
+	case "yaml":
+		op.fragment = d
+	}
+	return nil
+}
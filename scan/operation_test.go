@@ -136,3 +136,115 @@ func TestParseOpenAPIOperation(t *testing.T) {
 		})
 	}
 }
+
+func TestParser_collectOperation_Inherited(t *testing.T) {
+	parser := NewParser(NewLogger(LogLevelFatal))
+	spec, err := parser.GetSpec([]string{"testdata/handlers"})
+	if err != nil {
+		t.Fatalf("GetSpec() error = %v", err)
+	}
+
+	tests := []struct {
+		name         string
+		path         string
+		method       string
+		wantTags     []string
+		wantSecurity []string
+		wantParams   map[string]bool
+		wantCodes    map[string]string
+	}{
+		{
+			name:         "interface",
+			path:         "/v1/owners",
+			method:       "GET",
+			wantTags:     []string{"owners"},
+			wantSecurity: []string{"bearer"},
+			wantParams:   map[string]bool{"x-request-id": false},
+			wantCodes:    map[string]string{"200": "The owners", "400": "Invalid request", "500": "Internal error"},
+		},
+		{
+			name:         "method overrides",
+			path:         "/v1/owners/{id}",
+			method:       "GET",
+			wantTags:     []string{"owners", "lookup"},
+			wantSecurity: []string{},
+			wantParams:   map[string]bool{"id": true, "x-request-id": true},
+			wantCodes:    map[string]string{"200": "The owner", "400": "Invalid ID", "500": "Internal error"},
+		},
+		{
+			name:         "receiver type",
+			path:         "/admin/owners",
+			method:       "POST",
+			wantSecurity: []string{"oauth"},
+			wantParams:   map[string]bool{},
+			wantCodes:    map[string]string{"201": "Created owner"},
+		},
+		{
+			name:       "function",
+			path:       "/health",
+			method:     "GET",
+			wantParams: map[string]bool{},
+			wantCodes:  map[string]string{"204": "Healthy"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pathItem := spec.Paths.Find(tt.path)
+			if pathItem == nil {
+				t.Fatalf("path %s not found", tt.path)
+			}
+			op := pathItem.GetOperation(tt.method)
+			if op == nil {
+				t.Fatalf("operation %s %s not found", tt.method, tt.path)
+			}
+
+			if !reflect.DeepEqual(op.Tags, tt.wantTags) {
+				t.Errorf("tags = %v, want %v", op.Tags, tt.wantTags)
+			}
+
+			var security []string
+			if op.Security != nil {
+				security = []string{}
+				for _, requirement := range *op.Security {
+					for scheme := range requirement {
+						security = append(security, scheme)
+					}
+				}
+			}
+			if !reflect.DeepEqual(security, tt.wantSecurity) {
+				t.Errorf("security = %v, want %v", security, tt.wantSecurity)
+			}
+
+			params := map[string]bool{}
+			for _, param := range op.Parameters {
+				params[param.Value.Name] = param.Value.Required
+			}
+			if !reflect.DeepEqual(params, tt.wantParams) {
+				t.Errorf("parameters = %v, want %v", params, tt.wantParams)
+			}
+
+			codes := map[string]string{}
+			for code, response := range op.Responses {
+				codes[code] = *response.Value.Description
+			}
+			if !reflect.DeepEqual(codes, tt.wantCodes) {
+				t.Errorf("responses = %v, want %v", codes, tt.wantCodes)
+			}
+		})
+	}
+
+	// Malformed shared directives are reported once for all methods
+	var errs int
+	for _, d := range parser.Diagnostics() {
+		if d.Directive == "openapi:security" {
+			errs++
+		}
+	}
+	if errs != 1 {
+		t.Errorf("openapi:security diagnostics = %d, want 1", errs)
+	}
+	if spec.Paths.Find("/events") == nil || spec.Paths.Find("/events").Delete == nil {
+		t.Errorf("operations of AuditHandler must be generated without the malformed directive")
+	}
+}
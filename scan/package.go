@@ -0,0 +1,205 @@
+package scan
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// loadMode is the information loaded for the scanned packages and all of their dependencies.
+const loadMode = packages.NeedName | packages.NeedFiles | packages.NeedSyntax | packages.NeedTypes |
+	packages.NeedTypesInfo | packages.NeedImports | packages.NeedDeps
+
+// typeDecl is a type declaration found in one of the loaded packages.
+type typeDecl struct {
+	pkg  *packages.Package
+	file *ast.File
+	spec *ast.TypeSpec
+	doc  *ast.CommentGroup
+}
+
+// qualifiedName returns the import path qualified name of the declared type.
+func (d *typeDecl) qualifiedName() string {
+	if d.pkg == nil {
+		return d.spec.Name.Name
+	}
+	return d.pkg.PkgPath + "." + d.spec.Name.Name
+}
+
+// loadPackages loads all packages under dir with full type information.
+func (p *Parser) loadPackages(dir string) ([]*packages.Package, error) {
+	// The files may be reported with the symbolic links of dir resolved
+	dirs := []string{dir}
+	if resolved, err := filepath.EvalSymlinks(dir); err == nil && resolved != dir {
+		dirs = append(dirs, resolved)
+	}
+
+	pkgs, err := packages.Load(&packages.Config{
+		Mode: loadMode,
+		Dir:  dir,
+		Fset: p.fileSet,
+		ParseFile: func(fset *token.FileSet, filename string, src []byte) (*ast.File, error) {
+			file, err := parser.ParseFile(fset, filename, src, parser.ParseComments)
+			if err != nil || isInDir(filename, dirs) {
+				return file, err
+			}
+			// Only declarations are needed from dependencies, skip type checking their function bodies. String
+			// methods are kept as they enumerate the values of text encoded constants, see getStringValues.
+			for _, decl := range file.Decls {
+				if fn, ok := decl.(*ast.FuncDecl); ok && !(fn.Recv != nil && fn.Name.Name == "String") {
+					fn.Body = nil
+				}
+			}
+			return file, nil
+		},
+	}, "./...")
+	if err != nil {
+		return nil, err
+	}
+
+	for _, pkg := range pkgs {
+		for _, e := range pkg.Errors {
+			p.record(&Diagnostic{
+				Position: parsePosition(e.Pos),
+				Severity: SeverityWarning,
+				Message:  fmt.Sprintf("error loading package %s: %s", pkg.PkgPath, e.Msg),
+			})
+		}
+	}
+
+	// Index the packages and their dependencies so types can be followed across packages. Packages scanned
+	// from another directory are kept, as their dependency copies are loaded without function bodies.
+	packages.Visit(pkgs, nil, func(pkg *packages.Package) {
+		if p.isScanned(pkg.PkgPath) {
+			return
+		}
+		p.packages[pkg.PkgPath] = pkg
+		for _, file := range pkg.Syntax {
+			p.pkgFiles[p.fileSet.File(file.Pos())] = pkg
+		}
+	})
+	return pkgs, nil
+}
+
+// isInDir reports whether filename is located under any of dirs.
+func isInDir(filename string, dirs []string) bool {
+	for _, dir := range dirs {
+		if strings.HasPrefix(filename, dir+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
+}
+
+// packageOf returns the loaded package containing pos.
+func (p *Parser) packageOf(pos token.Pos) *packages.Package {
+	if !pos.IsValid() {
+		return nil
+	}
+	return p.pkgFiles[p.fileSet.File(pos)]
+}
+
+// objectOf returns the type name referenced by expr or nil if it cannot be resolved.
+func (p *Parser) objectOf(expr ast.Expr) *types.TypeName {
+	var ident *ast.Ident
+	switch t := expr.(type) {
+	case *ast.StarExpr:
+		return p.objectOf(t.X)
+	case *ast.IndexExpr:
+		return p.objectOf(t.X)
+	case *ast.IndexListExpr:
+		return p.objectOf(t.X)
+	case *ast.Ident:
+		ident = t
+	case *ast.SelectorExpr:
+		ident = t.Sel
+	default:
+		return nil
+	}
+
+	pkg := p.packageOf(ident.Pos())
+	if pkg == nil {
+		// Expressions parsed from annotations are resolved in the scope of the package being processed
+		return p.lookupTypeName(p.pkg, types.ExprString(expr))
+	}
+	if pkg.TypesInfo == nil {
+		return nil
+	}
+	obj, _ := pkg.TypesInfo.Uses[ident].(*types.TypeName)
+	return obj
+}
+
+// typeOf returns the type of expr or nil if the expression was not type checked.
+func (p *Parser) typeOf(expr ast.Expr) types.Type {
+	pkg := p.packageOf(expr.Pos())
+	if pkg == nil || pkg.TypesInfo == nil {
+		return nil
+	}
+	return pkg.TypesInfo.TypeOf(expr)
+}
+
+// lookupTypeDecl returns the declaration of the named type obj from the symbol table.
+// Predeclared types have no declaration.
+func (p *Parser) lookupTypeDecl(obj *types.TypeName) *typeDecl {
+	if obj == nil || obj.Pkg() == nil {
+		return nil
+	}
+
+	decl, ok := p.typeDecls[obj.Pkg().Path()+"."+obj.Name()]
+	if !ok {
+		p.logger.Debug("declaration of %s.%s not found", obj.Pkg().Path(), obj.Name())
+		return nil
+	}
+	return decl
+}
+
+// qualifiedName returns the qualified name of ts declared in the package being processed.
+func (p *Parser) qualifiedName(ts *ast.TypeSpec) string {
+	return (&typeDecl{pkg: p.pkg, spec: ts}).qualifiedName()
+}
+
+// lookupTypeName resolves a type named in an annotation. The name is either declared in pkg
+// or qualified with a package name or import path, e.g. `errors.ErrorResponse`.
+func (p *Parser) lookupTypeName(pkg *packages.Package, name string) *types.TypeName {
+	idx := strings.LastIndex(name, ".")
+	if idx == -1 {
+		if pkg == nil || pkg.Types == nil {
+			return nil
+		}
+		obj, _ := pkg.Types.Scope().Lookup(name).(*types.TypeName)
+		return obj
+	}
+
+	qualifier, typeName := name[:idx], name[idx+1:]
+	var candidates []*packages.Package
+	if pkg != nil {
+		for _, imp := range pkg.Imports {
+			candidates = append(candidates, imp)
+		}
+	}
+	paths := make([]string, 0, len(p.packages))
+	for path := range p.packages {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		candidates = append(candidates, p.packages[path])
+	}
+
+	for _, candidate := range candidates {
+		if candidate.Types == nil || (candidate.Name != qualifier && candidate.PkgPath != qualifier) {
+			continue
+		}
+		if obj, ok := candidate.Types.Scope().Lookup(typeName).(*types.TypeName); ok {
+			return obj
+		}
+	}
+	return nil
+}
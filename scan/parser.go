@@ -3,11 +3,13 @@ package scan
 import (
 	"github.com/getkin/kin-openapi/openapi3"
 	"go/ast"
-	"go/parser"
 	"go/token"
-	"os"
+	"go/types"
 	"path/filepath"
+	"sort"
 	"strings"
+
+	"golang.org/x/tools/go/packages"
 )
 
 // Parser is a struct that holds the state of the parser.
@@ -15,6 +17,7 @@ type Parser struct {
 	fileSet        *token.FileSet
 	spec           *openapi3.T
 	packageName    string
+	pkg            *packages.Package
 	file           *ast.File
 	logger         *Logger
 	structComments map[string]*structComment
@@ -29,12 +32,44 @@ type Parser struct {
 	structMap map[string]*ast.StructType
 	meta      string
 
+	scanned     []*scannedPackage
+	packages    map[string]*packages.Package
+	pkgFiles    map[*token.File]*packages.Package
+	typeDecls   map[string]*typeDecl
+	schemaNames map[string]string
+
+	typeArgs      map[*types.TypeName]ast.Expr
+	genericNaming GenericNaming
+	// instances are the schema names of instantiated generic types by instantiation and vice versa
+	instances     map[string]string
+	instanceNames map[string]string
+	typeMappings  map[string]*openapi3.Schema
+	inProgress    map[string]bool
+	inlining      map[string]bool
+
+	hoistAnonymous bool
+	requiredPolicy RequiredPolicy
+	nullablePolicy NullablePolicy
+	discriminators []*discriminatorCheck
+	security       []*securityCheck
+	wireSchemas    map[*typeDecl]*openapi3.Schema
+	enumValues     map[*typeDecl][]*enumValue
+	diagnostics    []*Diagnostic
+	// sharedOperations are the inherited operation directives by doc comment of interfaces and receiver types
+	sharedOperations map[*ast.CommentGroup]*openAPIOperation
+
 	//interfaces        map[string]*ast.TypeSpec
 }
 
+// scannedPackage is a package loaded from one of the scanned directories.
+type scannedPackage struct {
+	dir string
+	pkg *packages.Package
+}
+
 // NewParser creates a new instance of the Parser struct.
 func NewParser(logger *Logger) *Parser {
-	return &Parser{
+	p := &Parser{
 		logger:  logger,
 		fileSet: token.NewFileSet(),
 		spec: &openapi3.T{
@@ -52,16 +87,37 @@ func NewParser(logger *Logger) *Parser {
 				Schemas: map[string]*openapi3.SchemaRef{},
 			},
 		},
-		fieldComment:   map[string]*fieldComment{},
-		structComments: map[string]*structComment{},
-		typeMap:        make(map[string]*ast.TypeSpec),
-		schemaMap:      map[string]*openapi3.Schema{},
-		operations:     []*openAPIOperation{},
-		queue:          map[string]*ast.TypeSpec{},
-		fieldMap:       map[string]*ast.Field{},
-		structMap:      map[string]*ast.StructType{},
+		fieldComment:     map[string]*fieldComment{},
+		structComments:   map[string]*structComment{},
+		typeMap:          make(map[string]*ast.TypeSpec),
+		schemaMap:        map[string]*openapi3.Schema{},
+		operations:       []*openAPIOperation{},
+		queue:            map[string]*ast.TypeSpec{},
+		fieldMap:         map[string]*ast.Field{},
+		structMap:        map[string]*ast.StructType{},
+		packages:         map[string]*packages.Package{},
+		pkgFiles:         map[*token.File]*packages.Package{},
+		typeDecls:        map[string]*typeDecl{},
+		schemaNames:      map[string]string{},
+		typeArgs:         map[*types.TypeName]ast.Expr{},
+		instances:        map[string]string{},
+		instanceNames:    map[string]string{},
+		genericNaming:    GenericNamingConcat,
+		requiredPolicy:   RequiredPolicyExplicit,
+		nullablePolicy:   NullablePolicyExplicit,
+		typeMappings:     map[string]*openapi3.Schema{},
+		inProgress:       map[string]bool{},
+		inlining:         map[string]bool{},
+		wireSchemas:      map[*typeDecl]*openapi3.Schema{},
+		enumValues:       map[*typeDecl][]*enumValue{},
+		sharedOperations: map[*ast.CommentGroup]*openAPIOperation{},
 		//structs:        map[string]*ast.TypeSpec{},
 	}
+
+	for name, schema := range defaultTypeMappings {
+		p.typeMappings[name] = schema
+	}
+	return p
 }
 
 func (p *Parser) WithMetaPath(path string) *Parser {
@@ -69,64 +125,180 @@ func (p *Parser) WithMetaPath(path string) *Parser {
 	return p
 }
 
+// WithHoistAnonymous hoists anonymous struct fields into components named after the struct and the
+// field, e.g. `CreatePetRequestOptions`, instead of inlining them as nested objects.
+func (p *Parser) WithHoistAnonymous(hoist bool) *Parser {
+	p.hoistAnonymous = hoist
+	return p
+}
+
+// GetSpec generates the spec for the packages found in dirs. The declarations of all directories are
+// collected before any schema is resolved, so dirs may be passed in any order.
 func (p *Parser) GetSpec(dirs []string) (*openapi3.T, error) {
-	var err error
+	// Load the packages of every directory
 	for _, dir := range dirs {
-		err = p.parseDir(dir)
-		for key, ts := range p.typeMap {
-			p.createOpenAPISchema(key, ts)
-		}
-		for _, op := range p.operations {
-			p.generateOperation(op)
+		if err := p.parseDir(dir); err != nil {
+			return p.spec, err
 		}
 	}
-	return p.spec, err
+
+	// Index the type declarations of the scanned packages and their dependencies
+	p.buildSymbolTable()
+
+	// Collect the annotated declarations and operations
+	if err := p.collectDeclarations(); err != nil {
+		return p.spec, err
+	}
+
+	// Resolve the schemas now that every declaration is known
+	p.resolveSchemas()
+
+	// Emit the operations once all schemas are available
+	for _, op := range p.operations {
+		p.generateOperation(op)
+	}
+
+	// Validate the discriminators now that every schema is complete
+	p.validateDiscriminators()
+	// Validate the security requirements now that every scheme is declared
+	p.validateSecurity()
+	return p.spec, nil
 }
 
+// parseDir loads the packages found in dir. Packages that were already loaded from another directory are skipped.
 func (p *Parser) parseDir(dir string) error {
-	return filepath.Walk(dir, func(filePath string, info os.FileInfo, err error) error {
-		if err != nil {
-			return err
-		}
+	root, err := filepath.Abs(dir)
+	if err != nil {
+		return err
+	}
 
-		if info.IsDir() {
-			// Skip directories
-			p.logger.Debug("Processing directory: %s\n", filePath)
-			return nil
+	pkgs, err := p.loadPackages(root)
+	if err != nil {
+		return err
+	}
+
+	for _, pkg := range pkgs {
+		if p.isScanned(pkg.PkgPath) {
+			p.logger.Debug("skipping package %s already loaded from another directory", pkg.PkgPath)
+			continue
 		}
+		p.scanned = append(p.scanned, &scannedPackage{dir: root, pkg: pkg})
+	}
+	return nil
+}
 
-		// Parse only .go files
-		if filepath.Ext(filePath) != ".go" {
-			return nil
+func (p *Parser) isScanned(pkgPath string) bool {
+	for _, sp := range p.scanned {
+		if sp.pkg.PkgPath == pkgPath {
+			return true
 		}
+	}
+	return false
+}
 
-		// Parse the file
-		file, err := parser.ParseFile(token.NewFileSet(), filePath, nil, parser.ParseComments)
-		if err != nil {
-			return err
+// buildSymbolTable indexes the type declarations of all loaded packages by their qualified name.
+func (p *Parser) buildSymbolTable() {
+	for _, pkg := range p.packages {
+		for _, file := range pkg.Syntax {
+			for _, d := range file.Decls {
+				gd, ok := d.(*ast.GenDecl)
+				if !ok || gd.Tok != token.TYPE {
+					continue
+				}
+				for _, spec := range gd.Specs {
+					ts, ok := spec.(*ast.TypeSpec)
+					if !ok {
+						continue
+					}
+					doc := ts.Doc
+					if doc == nil {
+						doc = gd.Doc
+					}
+					decl := &typeDecl{pkg: pkg, file: file, spec: ts, doc: doc}
+					p.typeDecls[decl.qualifiedName()] = decl
+				}
+			}
 		}
+	}
+	p.logger.Debug("indexed %d type declarations", len(p.typeDecls))
+}
+
+// collectDeclarations extracts the meta information, annotated types and operations of the scanned packages.
+func (p *Parser) collectDeclarations() error {
+	for _, sp := range p.scanned {
+		p.logger.Debug("Processing package: %s\n", sp.pkg.PkgPath)
+		p.pkg = sp.pkg
+
+		for _, file := range sp.pkg.Syntax {
+			filePath, err := filepath.Rel(sp.dir, p.fileSet.Position(file.Package).Filename)
+			if err != nil {
+				return err
+			}
 
-		p.file = file
-
-		// Iterate through the comments in the file
-		for _, comment := range file.Comments {
-			if comment.Pos() < file.Package {
-				switch len(p.meta) {
-				case 0:
-					p.extractOpenAPIInfo(comment)
-					break
-				default:
-					if p.meta == filePath[len(dir)+1:] {
+			p.file = file
+			p.checkDirectives(file)
+
+			// Iterate through the comments in the file
+			for _, comment := range file.Comments {
+				if comment.Pos() < file.Package {
+					switch len(p.meta) {
+					case 0:
 						p.extractOpenAPIInfo(comment)
-						break
+					default:
+						if p.meta == filepath.ToSlash(filePath) {
+							p.extractOpenAPIInfo(comment)
+						}
 					}
 				}
 			}
+
+			// Process the file
+			if err = p.ProcessFile(filePath, file); err != nil {
+				return err
+			}
 		}
+	}
+	return nil
+}
+
+// resolveSchemas creates the schemas of all collected types in a stable order.
+func (p *Parser) resolveSchemas() {
+	keys := make([]string, 0, len(p.typeMap))
+	for key := range p.typeMap {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
 
-		// Process the file
-		return p.ProcessFile(filePath[len(dir)+1:], file)
-	})
+	for _, key := range keys {
+		ts := p.typeMap[key]
+		if _, ok := ts.Type.(*ast.StructType); ok {
+			p.createOpenAPISchema(key, ts)
+			continue
+		}
+		if _, ok := p.schemaMap[key]; ok {
+			continue
+		}
+		if decl := p.declOf(ts); decl != nil {
+			if isCollection(decl) {
+				p.createCollectionSchema(key, decl)
+				continue
+			}
+			if values := p.getEnumValues(decl); len(values) > 0 {
+				p.createEnumSchema(key, decl, values)
+				continue
+			}
+			if schema := p.getWireSchema(decl); schema != nil {
+				p.schemaMap[key] = schema
+				continue
+			}
+		}
+		schemaRef := p.ParseTypeExpr(key, ts.Type)
+		if schemaRef == nil {
+			p.logger.Debug("unsupported type for schema %s", key)
+			continue
+		}
+		p.schemaMap[key] = schemaRef.Value
+	}
 }
 
 func (p *Parser) ProcessFile(path string, file *ast.File) error {
@@ -144,16 +316,22 @@ func (p *Parser) ProcessFile(path string, file *ast.File) error {
 				// Handle type declarations
 				for _, spec := range declType.Specs {
 					if ts, ok := spec.(*ast.TypeSpec); ok {
+						if ts.TypeParams != nil && !isInterface(ts) {
+							// Generic types are instantiated where they are used
+							p.logger.Debug("skipping generic type %s at %s", ts.Name.Name, path)
+							continue
+						}
 
 						switch ts.Type.(type) {
-						case *ast.Ident:
+						case *ast.Ident, *ast.ArrayType, *ast.MapType:
 							key := p.extractStructComments(ts.Name.Name, declType.Doc)
 							if key == nil {
 								p.logger.Debug("invalid config for schema %s at %s", ts.Name.Name, path)
 								continue
 							}
-							schemaRef := p.ParseTypeExpr(*key, ts.Type)
-							p.schemaMap[*key] = schemaRef.Value
+							// The schema is resolved once all declarations are collected
+							p.schemaNames[p.qualifiedName(ts)] = *key
+							p.typeMap[*key] = ts
 						case *ast.StructType:
 
 							t, ok := ts.Type.(*ast.StructType)
@@ -172,7 +350,7 @@ func (p *Parser) ProcessFile(path string, file *ast.File) error {
 									continue
 								}
 								if field.Doc == nil {
-									p.logger.Debug("openapi annotations not found for %s", key)
+									p.logger.Debug("openapi annotations not found for %s", *key)
 									continue
 								}
 								// TODO: Fields are extracted twice due to mapping, cache Struct/Field name with openapi:scheme/name
@@ -183,6 +361,7 @@ func (p *Parser) ProcessFile(path string, file *ast.File) error {
 								p.fieldMap[*fieldName] = field
 							}
 
+							p.schemaNames[p.qualifiedName(ts)] = *key
 							p.typeMap[*key] = ts
 							p.structMap[*key] = t
 						case *ast.InterfaceType:
@@ -191,6 +370,12 @@ func (p *Parser) ProcessFile(path string, file *ast.File) error {
 								break
 							}
 
+							// The directives of the interface apply to all of its methods
+							doc := ts.Doc
+							if doc == nil {
+								doc = declType.Doc
+							}
+							shared := p.sharedOperation(ts.Name.Name, doc)
 							for _, field := range iface.Methods.List {
 								if field == nil || field.Names == nil && len(field.Names) == 0 {
 									p.logger.Debug("fields not found for %s", ts.Name.Name)
@@ -201,12 +386,7 @@ func (p *Parser) ProcessFile(path string, file *ast.File) error {
 									p.logger.Debug("openapi annotations not found for %s", key)
 									continue
 								}
-								openAPIOp, err := extractOpenAPIOperation(key, field.Doc)
-								if err != nil {
-									p.logger.Debug(err.Error())
-									continue
-								}
-								p.operations = append(p.operations, openAPIOp)
+								p.collectOperation(key, field.Doc, shared)
 							}
 
 						}
@@ -221,12 +401,7 @@ func (p *Parser) ProcessFile(path string, file *ast.File) error {
 				continue
 			}
 
-			openAPIOp, err := extractOpenAPIOperation(fn.Name.Name, fn.Doc)
-			if err != nil {
-				p.logger.Debug(err.Error())
-				continue
-			}
-			p.operations = append(p.operations, openAPIOp)
+			p.collectOperation(fn.Name.Name, fn.Doc, p.receiverOperation(fn))
 		default:
 			p.logger.Debug("not supported")
 		}
@@ -0,0 +1,166 @@
+package scan
+
+import (
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// testSpecs caches the specs generated with the default options by dirs, as loading the packages of a
+// fixture is slow. Tests must not modify them.
+var (
+	testSpecsMu sync.Mutex
+	testSpecs   = map[string]*openapi3.T{}
+)
+
+func getTestSpec(t *testing.T, dirs ...string) *openapi3.T {
+	t.Helper()
+	testSpecsMu.Lock()
+	defer testSpecsMu.Unlock()
+
+	key := strings.Join(dirs, string(rune(0)))
+	if spec, ok := testSpecs[key]; ok {
+		return spec
+	}
+	spec, err := NewParser(NewLogger(LogLevelError)).GetSpec(dirs)
+	if err != nil {
+		t.Fatalf("GetSpec() error = %v", err)
+	}
+	testSpecs[key] = spec
+	return spec
+}
+
+func TestParser_GetSpec_ResolvesPackages(t *testing.T) {
+	spec := getTestSpec(t, "testdata/pets")
+
+	tests := []struct {
+		name            string
+		schema          string
+		property        string
+		wantRef         string
+		wantDescription string
+		nullable        bool
+	}{
+		{
+			name:            "type in same package",
+			schema:          "CreatePetResponse",
+			property:        "category",
+			wantRef:         "#/components/schemas/Category",
+			wantDescription: "Type of pet",
+			nullable:        true,
+		},
+		{
+			name:            "pointer to type in another package",
+			schema:          "CreatePetResponse",
+			property:        "error",
+			wantRef:         "#/components/schemas/ErrorResponse",
+			wantDescription: "Error returned while creating the pet",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schemaRef, ok := spec.Components.Schemas[tt.schema]
+			if !ok {
+				t.Fatalf("schema %s not found", tt.schema)
+			}
+			property, ok := schemaRef.Value.Properties[tt.property]
+			if !ok {
+				t.Fatalf("property %s not found in %s", tt.property, tt.schema)
+			}
+			// Annotations are set next to the reference
+			if property.Value.Nullable != tt.nullable || len(property.Value.AllOf) != 1 {
+				t.Fatalf("property %s = %+v, want allOf with nullable %v", tt.property, property.Value, tt.nullable)
+			}
+			if property.Value.Description != tt.wantDescription {
+				t.Errorf("property %s description = %s, want %s", tt.property, property.Value.Description, tt.wantDescription)
+			}
+			property = property.Value.AllOf[0]
+			if property.Ref != tt.wantRef {
+				t.Errorf("property %s ref = %s, want %s", tt.property, property.Ref, tt.wantRef)
+			}
+		})
+	}
+
+	errorResponse, ok := spec.Components.Schemas["ErrorResponse"]
+	if !ok {
+		t.Fatalf("schema ErrorResponse not found")
+	}
+	if _, ok := errorResponse.Value.Properties["msg"]; !ok {
+		t.Errorf("property msg not found in ErrorResponse")
+	}
+
+	response := spec.Paths["/pets"].Post.Responses.Get(400)
+	if response == nil || response.Value.Content.Get("application/json").Schema.Value != errorResponse.Value {
+		t.Errorf("response 400 of createPet does not use ErrorResponse")
+	}
+}
+
+func TestParser_GetSpec_DirOrder(t *testing.T) {
+	tests := []struct {
+		name string
+		dirs []string
+	}{
+		{
+			name: "dependencies first",
+			dirs: []string{"testdata/pets/errors", "testdata/pets"},
+		},
+		{
+			name: "dependencies last",
+			dirs: []string{"testdata/pets", "testdata/pets/errors"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spec := getTestSpec(t, tt.dirs...)
+
+			if len(spec.Paths) != 1 || spec.Paths["/pets"] == nil || spec.Paths["/pets"].Post == nil {
+				t.Fatalf("GetSpec() paths = %v, want a single POST /pets", spec.Paths)
+			}
+			for _, name := range []string{"CreatePetResponse", "Category", "ErrorResponse"} {
+				if _, ok := spec.Components.Schemas[name]; !ok {
+					t.Errorf("schema %s not found", name)
+				}
+			}
+		})
+	}
+
+	// Enums declared by a scanned package are resolved whatever the order of the dirs
+	for _, dirs := range [][]string{{"testdata/shared/levels", "testdata/shared/api"}, {"testdata/shared/api", "testdata/shared/levels"}} {
+		spec := getTestSpec(t, dirs...)
+		if ref := getTestRef(getTestProperty(t, spec, "Event", "level")).Ref; ref != "#/components/schemas/Level" {
+			t.Errorf("GetSpec(%v) Event level ref = %s, want #/components/schemas/Level", dirs, ref)
+		}
+	}
+}
+
+func TestParser_GetSpec_NameCollisions(t *testing.T) {
+	spec := getTestSpec(t, "testdata/collisions")
+
+	refs := map[string]string{
+		"aError": "#/components/schemas/Error",
+		"bError": "#/components/schemas/BError",
+		"aPage":  "#/components/schemas/PageItem",
+		"bPage":  "#/components/schemas/PageBItem",
+	}
+	for property, want := range refs {
+		ref := getTestRef(getTestProperty(t, spec, "Result", property)).Ref
+		if ref != want {
+			t.Errorf("property %s ref = %s, want %s", property, ref, want)
+		}
+	}
+	if _, ok := spec.Components.Schemas["Error"].Value.Properties["code"]; !ok {
+		t.Errorf("schema Error is not a.Error")
+	}
+	if _, ok := spec.Components.Schemas["BError"].Value.Properties["message"]; !ok {
+		t.Errorf("schema BError is not b.Error")
+	}
+	for name, want := range map[string]string{"PageItem": "#/components/schemas/Item", "PageBItem": "#/components/schemas/BItem"} {
+		if ref := spec.Components.Schemas[name].Value.Properties["items"].Value.Items.Ref; ref != want {
+			t.Errorf("schema %s items ref = %s, want %s", name, ref, want)
+		}
+	}
+}
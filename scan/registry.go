@@ -0,0 +1,63 @@
+package scan
+
+import (
+	"fmt"
+	"go/types"
+	"os"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"gopkg.in/yaml.v3"
+)
+
+// defaultTypeMappings maps well-known types to the schema of their JSON serialization.
+var defaultTypeMappings = map[string]*openapi3.Schema{
+	"time.Time":                                 {Type: "string", Format: "date-time"},
+	"time.Duration":                             {Type: "integer", Format: "int64"},
+	"encoding/json.RawMessage":                  {},
+	"encoding/json.Number":                      {Type: "number"},
+	"net.IP":                                    {Type: "string"},
+	"net/netip.Addr":                            {Type: "string"},
+	"net/url.URL":                               {Type: "string", Format: "uri"},
+	"math/big.Int":                              {Type: "integer"},
+	"github.com/google/uuid.UUID":               {Type: "string", Format: "uuid"},
+	"github.com/gofrs/uuid.UUID":                {Type: "string", Format: "uuid"},
+	"github.com/shopspring/decimal.Decimal":     {Type: "string", Format: "decimal", Pattern: `^-?\d+(\.\d+)?$`},
+	"github.com/shopspring/decimal.NullDecimal": {Type: "string", Format: "decimal", Nullable: true},
+}
+
+// LoadTypeMappings loads the schemas of Go types from the YAML or JSON file at path. Types are keyed by
+// their import path qualified name, e.g. `github.com/google/uuid.UUID`, and override the defaults.
+func (p *Parser) LoadTypeMappings(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	mappings := map[string]*openapi3.Schema{}
+	if err = yaml.Unmarshal(data, &mappings); err != nil {
+		return fmt.Errorf("invalid type mappings %s: %w", path, err)
+	}
+
+	for name, schema := range mappings {
+		if schema == nil {
+			schema = &openapi3.Schema{}
+		}
+		p.logger.Debug("mapping type %s to %s", name, schema.Type)
+		p.typeMappings[name] = schema
+	}
+	return nil
+}
+
+// getTypeMapping returns a copy of the schema mapped to the named type obj or nil if it is not mapped.
+func (p *Parser) getTypeMapping(obj *types.TypeName) *openapi3.Schema {
+	if obj == nil || obj.Pkg() == nil {
+		return nil
+	}
+
+	schema, ok := p.typeMappings[obj.Pkg().Path()+"."+obj.Name()]
+	if !ok {
+		return nil
+	}
+	mapped := *schema
+	return &mapped
+}
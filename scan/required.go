@@ -0,0 +1,73 @@
+package scan
+
+import (
+	"go/ast"
+	"go/types"
+	"strings"
+)
+
+// RequiredPolicy decides which fields are required when they are neither annotated with openapi:required
+// nor validated as required.
+type RequiredPolicy string
+
+const (
+	// RequiredPolicyExplicit requires only annotated and validated fields.
+	RequiredPolicyExplicit RequiredPolicy = "explicit"
+	// RequiredPolicyOmitEmpty additionally requires fields without `omitempty` or `omitzero`, as they are always serialized.
+	RequiredPolicyOmitEmpty RequiredPolicy = "omitempty"
+	// RequiredPolicyPointer additionally requires fields without `omitempty` or `omitzero` that are not pointers.
+	RequiredPolicyPointer RequiredPolicy = "pointer"
+)
+
+// WithRequiredPolicy sets the policy used to infer the required fields.
+func (p *Parser) WithRequiredPolicy(policy RequiredPolicy) *Parser {
+	switch policy {
+	case RequiredPolicyExplicit, RequiredPolicyOmitEmpty, RequiredPolicyPointer:
+		p.requiredPolicy = policy
+	default:
+		p.logger.Warn("unsupported required policy %s, using %s", policy, RequiredPolicyExplicit)
+		p.requiredPolicy = RequiredPolicyExplicit
+	}
+	return p
+}
+
+// isRequired reports whether the field is required. The openapi:required annotation takes precedence
+// over the `validate` and `binding` tags, which take precedence over the required policy.
+func (p *Parser) isRequired(fc *fieldComment, field *ast.Field, opts tagOptions) bool {
+	if fc != nil && fc.Required != nil {
+		return *fc.Required
+	}
+
+	if hasRequiredRule(field, "validate") || hasRequiredRule(field, "binding") {
+		return true
+	}
+
+	omitted := opts.Contains("omitempty") || opts.Contains("omitzero")
+	switch p.requiredPolicy {
+	case RequiredPolicyOmitEmpty:
+		return !omitted
+	case RequiredPolicyPointer:
+		return !omitted && !p.isPointer(field.Type)
+	}
+	return false
+}
+
+// isPointer reports whether expr is a pointer type.
+func (p *Parser) isPointer(expr ast.Expr) bool {
+	if t := p.typeOf(expr); t != nil {
+		_, ok := t.(*types.Pointer)
+		return ok
+	}
+	_, ok := expr.(*ast.StarExpr)
+	return ok
+}
+
+// hasRequiredRule reports whether the validation tag key of the field has the `required` rule.
+func hasRequiredRule(field *ast.Field, key string) bool {
+	for _, rule := range strings.Split(getTag(field, key), ",") {
+		if rule == "required" {
+			return true
+		}
+	}
+	return false
+}
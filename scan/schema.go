@@ -4,26 +4,46 @@ import (
 	"fmt"
 	"github.com/getkin/kin-openapi/openapi3"
 	"go/ast"
+	"go/token"
+	"go/types"
 	"reflect"
 	"strings"
 )
 
 type structComment struct {
-	Schema bool
-	Name   string
-	XML    xml
+	Schema        bool
+	Name          string
+	XML           xml
+	Description   string
+	Discriminator string
+	Fragment      *directive
 }
 
 type fieldComment struct {
 	Description string
 	Example     string
 	Deprecated  bool
-	Nullable    bool
+	Nullable    *bool
+	Required    *bool
 	Format      string
 	Default     string
 	Name        string
-	Enum        []interface{}
+	Enum        []string
 	OneOf       []string
+	AnyOf       []string
+	AllOf       []string
+	// Discriminator is the property name and mapping of the discriminator, e.g. `petType cat=Cat`
+	Discriminator string
+	Type          string
+	Title         string
+	ReadOnly      bool
+	WriteOnly     bool
+	// Constraints are the values of the constraint annotations keyed by keyword, e.g. `minimum`
+	Constraints map[string]string
+	// Positions are the positions of the annotation comments keyed by annotation, e.g. `example`
+	Positions map[string]token.Pos
+	// Fragment is the openapi:yaml block merged into the field schema
+	Fragment *directive
 }
 
 type xml struct {
@@ -41,6 +61,21 @@ func (p *Parser) createOpenAPISchema(structNameInSchema string, ts *ast.TypeSpec
 		p.logger.Fatal("schema not found for `%s`", structNameInSchema)
 	}
 
+	if schemaRef, ok := p.spec.Components.Schemas[structNameInSchema]; ok {
+		if p.inProgress[structNameInSchema] {
+			p.logger.Debug("recursive reference to %s", structNameInSchema)
+		}
+		return schemaRef.Value
+	}
+
+	if decl := p.declOf(ts); decl != nil {
+		if schema := p.getWireSchema(decl); schema != nil {
+			p.schemaMap[structNameInSchema] = schema
+			p.spec.Components.Schemas[structNameInSchema] = &openapi3.SchemaRef{Value: schema}
+			return schema
+		}
+	}
+
 	var schema *openapi3.Schema
 	if schema, ok = p.schemaMap[structNameInSchema]; !ok {
 		p.logger.Debug("creating schema for %s", structNameInSchema)
@@ -53,143 +88,302 @@ func (p *Parser) createOpenAPISchema(structNameInSchema string, ts *ast.TypeSpec
 		p.logger.Debug("found schema for %s", structNameInSchema)
 	}
 
-	required := []string{}
+	var allOf openapi3.SchemaRefs
 
 	// Parse if nested object
-
 	if schema.Type == "object" {
-
 		structType := ts.Type.(*ast.StructType)
 		if structType.Fields == nil || len(structType.Fields.List) == 0 {
 			// If the struct has no fields, there's nothing to do.
 			return nil
 		}
 
-		for _, field := range structType.Fields.List {
-			if field.Tag == nil {
-				// If the field has no tag, skip it.
+		// Register the component before parsing the fields, so recursive types reference it instead of
+		// being parsed again
+		p.schemaMap[structNameInSchema] = schema
+		p.spec.Components.Schemas[structNameInSchema] = &openapi3.SchemaRef{Value: schema}
+		p.inProgress[structNameInSchema] = true
+		allOf = p.parseStructFields(structNameInSchema, structType, schema)
+		delete(p.inProgress, structNameInSchema)
+	}
+
+	composeAllOf(schema, allOf)
+
+	if len(sc.Description) != 0 {
+		schema.Description = sc.Description
+	}
+	if len(sc.XML.Name) != 0 {
+		schema.XML = &openapi3.XML{Name: sc.XML.Name}
+	}
+	if len(sc.Discriminator) != 0 {
+		p.addDiscriminator(structNameInSchema, ts.Pos(), schema, sc.Discriminator, openapi3.SchemaRefs{
+			openapi3.NewSchemaRef(fmt.Sprintf("#/components/schemas/%s", structNameInSchema), schema),
+		})
+	}
+	p.applyFragment(structNameInSchema, sc.Fragment, schema)
+
+	p.schemaMap[structNameInSchema] = schema
+	p.spec.Components.Schemas[structNameInSchema] = &openapi3.SchemaRef{Value: schema}
+	return schema
+}
+
+// parseStructFields adds the properties for the fields of structType to schema. References to embedded
+// structs annotated with openapi:allOf are returned so that they can be composed with the schema.
+func (p *Parser) parseStructFields(structNameInSchema string, structType *ast.StructType, schema *openapi3.Schema) openapi3.SchemaRefs {
+	var allOf openapi3.SchemaRefs
+	required := []string{}
+	// Promoted fields may be shadowed, only properties declared by the struct itself must be unique
+	declared := map[string]bool{}
+
+	for _, field := range structType.Fields.List {
+		tag := getJSONTag(field)
+		if tag == "-" {
+			p.logger.Info("skipped parsing for field %s with json tag `-`", getFieldName(field))
+			continue
+		}
+
+		jsonName, opts := parseJSONTag(tag)
+		if len(jsonName) > 0 && !isValidJSONName(jsonName) {
+			p.warn(field.Tag.Pos(), "", "invalid json name %q for %s/%s, using the field name", jsonName, structNameInSchema, getFieldName(field))
+			jsonName = ""
+		}
+
+		embedded := len(field.Names) == 0
+		if embedded && len(jsonName) == 0 && p.isStruct(field.Type) {
+			// Embedded structs without a json name are promoted, see encoding/json
+			if ref := p.addEmbeddedField(structNameInSchema, field, schema); ref != nil {
+				allOf = append(allOf, ref)
+			}
+			continue
+		}
+
+		for _, goName := range getFieldNames(field) {
+			if !token.IsExported(goName) && !(embedded && p.isStruct(field.Type)) {
+				p.logger.Debug("skipped unexported field %s/%s", structNameInSchema, goName)
 				continue
 			}
-			//
 
-			fieldName := p.extractFieldComments(structNameInSchema, field.Names[0].Name, field.Doc)
+			propertyName := jsonName
+			if len(propertyName) == 0 {
+				propertyName = goName
+			}
+			if declared[propertyName] {
+				p.warn(field.Pos(), "", "duplicate property %s in %s", propertyName, structNameInSchema)
+			}
+			declared[propertyName] = true
+
+			fieldName := p.extractFieldComments(structNameInSchema, goName, field.Doc)
 			if fieldName == nil {
-				p.logger.Fatal("no openapi:name found for %s/%s", ts.Name.Name, field.Names[0].Name)
+				p.logger.Fatal("no openapi:name found for %s/%s", structNameInSchema, goName)
 			}
 
 			// Get the name and type of the field.
 			fc, ok := p.fieldComment[*fieldName]
 			if !ok {
-				p.logger.Warn("no openapi tags found for %s/%s", structNameInSchema, field.Names[0].Name)
-				//return nil
+				p.warn(field.Pos(), "", "no openapi tags found for %s/%s", structNameInSchema, goName)
 			}
 
-			p.logger.Debug("parsing schema %s with field %s", structNameInSchema, field.Names[0].Name)
-			fieldSchemaRef, jsonTag := p.createFieldSchema(structNameInSchema, fc, field, required)
-			if len(jsonTag) == 0 {
-				p.logger.Info("no json tags found for %s/%s", structNameInSchema, field.Names[0].Name)
-				continue
+			p.logger.Debug("parsing schema %s with field %s", structNameInSchema, goName)
+			schema.Properties[propertyName] = p.createFieldSchema(structNameInSchema, fc, field, opts)
+			if p.isRequired(fc, field, opts) {
+				required = append(required, propertyName)
 			}
+			p.addComposition(structNameInSchema+"/"+propertyName, field, schema.Properties[propertyName], fc)
+			if fc != nil {
+				schema.Properties[propertyName] = p.applySchemaFragment(structNameInSchema+"/"+propertyName, fc.Fragment, schema.Properties[propertyName])
+			}
+		}
+	}
 
-			schema.Type = getOpenAPIFieldType(ts.Type)
-			schema.Properties[jsonTag] = fieldSchemaRef
-
-			if fc != nil && len(fc.OneOf) > 0 {
+	// Properties declared by the struct shadow the promoted properties, including whether they are required
+	var promoted []string
+	for _, name := range schema.Required {
+		if !declared[name] {
+			promoted = append(promoted, name)
+		}
+	}
+	schema.Required = append(promoted, required...)
+	return allOf
+}
 
-				oneOfSchema := openapi3.NewOneOfSchema()
-				p.logger.Debug("found openapi:oneOf")
-				for _, name := range fc.OneOf {
-					_, ok := p.structMap[name]
-					if !ok {
-						// Continue with a waring
-						p.logger.Warn("oneOf field %s not found", name)
-					}
+// composeAllOf composes the embedded schemas allOf with the properties declared by the struct itself.
+// The schema is updated in place as recursive fields may already reference it.
+func composeAllOf(schema *openapi3.Schema, allOf openapi3.SchemaRefs) {
+	if len(allOf) == 0 {
+		return
+	}
+	own := *schema
+	*schema = openapi3.Schema{AllOf: append(allOf, openapi3.NewSchemaRef("", &own))}
+}
 
-					oneOfSchema.OneOf = append(oneOfSchema.OneOf, openapi3.NewSchemaRef(fmt.Sprintf("#/components/schemas/%s", name), nil))
-				}
-				schema.Properties[jsonTag].Value = oneOfSchema
-				schema.Properties[jsonTag].Ref = ""
-			} else if structField, isStructField := field.Type.(*ast.StructType); isStructField {
-				nestedSchema := p.createOpenAPISchema(structNameInSchema, &ast.TypeSpec{Name: &ast.Ident{Name: ""}, Type: structField})
-				if nestedSchema != nil {
-					schema.Properties[jsonTag].Ref = fmt.Sprintf("#/components/schemas/%s", field.Names[0].Name)
-					//schema.Properties[jsonTag].Value = nestedSchema
-				}
-			}
-		}
+// parseAnonymousStruct returns the OpenAPI schema for an anonymous struct. The struct is inlined as a
+// nested object, or hoisted into a component named key if anonymous structs are hoisted.
+func (p *Parser) parseAnonymousStruct(key string, structType *ast.StructType) *openapi3.SchemaRef {
+	schema := &openapi3.Schema{Type: "object", Properties: map[string]*openapi3.SchemaRef{}}
+	if structType.Fields == nil || len(structType.Fields.List) == 0 {
+		return openapi3.NewSchemaRef("", schema)
 	}
 
-	if len(sc.XML.Name) != 0 {
-		schema.XML = &openapi3.XML{Name: sc.XML.Name}
+	if !p.hoistAnonymous {
+		composeAllOf(schema, p.parseStructFields(key, structType, schema))
+		return openapi3.NewSchemaRef("", schema)
 	}
 
-	schema.Required = required
-	p.schemaMap[structNameInSchema] = schema
-	p.spec.Components.Schemas[structNameInSchema] = &openapi3.SchemaRef{Value: schema}
-	return schema
+	if _, ok := p.structComments[key]; !ok {
+		p.logger.Debug("hoisting anonymous struct %s", key)
+		p.structComments[key] = &structComment{Schema: true, Name: key}
+	}
+	return &openapi3.SchemaRef{
+		Ref:   fmt.Sprintf("#/components/schemas/%s", key),
+		Value: p.createOpenAPISchema(key, &ast.TypeSpec{Name: ast.NewIdent(key), Type: structType}),
+	}
 }
 
-func (p *Parser) createFieldSchema(name string, fc *fieldComment, field *ast.Field, required []string) (*openapi3.SchemaRef, string) {
-	// Parse the JSON tag to get the field name and options.
-	tag := reflect.StructTag(field.Tag.Value[1 : len(field.Tag.Value)-1]).Get("json")
-	jsonTag, opts := parseJSONTag(tag)
+// addEmbeddedField flattens the fields promoted by an embedded struct into schema. Fields declared
+// by the embedding struct take precedence over promoted fields. If the embedded field is annotated
+// with openapi:allOf, a reference to the embedded struct is returned instead.
+func (p *Parser) addEmbeddedField(structNameInSchema string, field *ast.Field, schema *openapi3.Schema) *openapi3.SchemaRef {
+	decl := p.lookupTypeDecl(p.objectOf(field.Type))
+	if decl == nil {
+		p.warn(field.Pos(), "", "embedded field %s of %s could not be resolved", getFieldName(field), structNameInSchema)
+		return nil
+	}
 
-	if jsonTag == "" {
-		// Skip fields without JSON tags
-		p.logger.Info("json tag not found for field %s", field.Names[0].Name)
-		return nil, ""
-	} else if jsonTag == "-" {
-		p.logger.Info("skipped parsing for field %s with json tag `-`", field.Names[0].Name)
-		return nil, ""
+	structType, ok := decl.spec.Type.(*ast.StructType)
+	if !ok {
+		p.warn(field.Pos(), "", "embedded field %s of %s is not a struct", getFieldName(field), structNameInSchema)
+		return nil
 	}
 
-	if sc, ok := p.schemaMap[fc.Name]; ok {
-		return openapi3.NewSchemaRef("", sc), jsonTag
+	args := getTypeArgs(field.Type)
+	if hasAnnotation(field.Doc, "openapi:allOf") {
+		if len(args) > 0 {
+			// Instantiated generic structs are components named after their type arguments
+			return p.ParseTypeExpr(structNameInSchema, field.Type)
+		}
+		name := p.registerTypeDecl(decl)
+		return &openapi3.SchemaRef{
+			Ref:   fmt.Sprintf("#/components/schemas/%s", name),
+			Value: p.createOpenAPISchema(name, decl.spec),
+		}
 	}
+
+	if !p.startInline(structNameInSchema, decl) {
+		return nil
+	}
+	defer p.endInline(decl)
+
+	if len(args) > 0 {
+		// The promoted fields of generic structs are parsed with the type parameters bound to the arguments
+		params := getTypeParams(decl)
+		if len(params) != len(args) {
+			p.warn(field.Pos(), "", "embedded field %s of %s expects %d type arguments, got %d", getFieldName(field), structNameInSchema, len(params), len(args))
+			return nil
+		}
+		defer p.bindTypeParams(params, args)()
+	}
+
+	embedded := &openapi3.Schema{Type: "object", Properties: map[string]*openapi3.SchemaRef{}}
+	if structType.Fields != nil {
+		p.parseStructFields(decl.spec.Name.Name, structType, embedded)
+	}
+
+	for _, name := range sortedKeys(embedded.Properties) {
+		if _, ok := schema.Properties[name]; ok {
+			p.logger.Debug("promoted field %s of %s is shadowed in %s", name, decl.spec.Name.Name, structNameInSchema)
+			continue
+		}
+		schema.Properties[name] = embedded.Properties[name]
+	}
+	for _, name := range embedded.Required {
+		if schema.Properties[name] == embedded.Properties[name] {
+			schema.Required = append(schema.Required, name)
+		}
+	}
+	return nil
+}
+
+func (p *Parser) createFieldSchema(name string, fc *fieldComment, field *ast.Field, opts tagOptions) *openapi3.SchemaRef {
 	// Parse the type of the field into an OpenAPI schema.
 	//p.logger.Info("Parsing type expression for %s/%s ", cwd, field.Names[0].Name)
-	fieldSchemaRef := p.ParseTypeExpr(fc.Name, field.Type)
+	// Anonymous structs of the field are named after the struct and the field
+	fieldSchemaRef := p.ParseTypeExpr(name+exported(fc.Name), field.Type)
+	fieldKey := name + "/" + fc.Name
+	overridden := false
+	if len(fc.Type) > 0 {
+		if schema, err := parseTypeAnnotation(fc.Type); err != nil {
+			p.warn(fc.Positions["type"], "openapi:type", "invalid value for %s: %s", fieldKey, err)
+		} else {
+			fieldSchemaRef, overridden = openapi3.NewSchemaRef("", schema), true
+		}
+	}
 	if fieldSchemaRef == nil {
 		// If the field type cannot be parsed, skip it.
-		return openapi3.NewSchemaRef("", openapi3.NewSchema()), jsonTag
-	}
-
-	// If the field is required, add it to the list of required fields.
-	if opts.Contains("required") {
-		required = append(required, jsonTag)
+		return openapi3.NewSchemaRef("", openapi3.NewSchema())
 	}
 
 	// Parse field and assign type to field SchemaRef
 	if fieldSchemaRef.Value == nil {
 		fieldSchemaRef.Value = openapi3.NewSchema()
 	}
-	fieldSchemaRef.Value.Type = getOpenAPIFieldType(field.Type)
-
-	if fc != nil {
-		if len(fc.Example) > 0 {
-			fieldSchemaRef.Value.Example = fc.Example
-		}
-		if len(fc.Description) > 0 {
-			fieldSchemaRef.Value.Description = fc.Description
-		}
-		if len(fc.Format) > 0 {
-			fieldSchemaRef.Value.Format = fc.Format
+	if opts.Contains("string") && p.isQuotable(field.Type) {
+		// Scalars with the string option are encoded as JSON strings
+		fieldSchemaRef = openapi3.NewSchemaRef("", &openapi3.Schema{Type: "string"})
+	} else if len(fieldSchemaRef.Ref) > 0 {
+		// Annotations must not leak into the referenced component, they are set next to it in allOf. Values and
+		// constraints are converted according to the type of the component.
+		p.applyValidation(fieldKey, field, fieldSchemaRef)
+		wrapper := &openapi3.Schema{Type: fieldSchemaRef.Value.Type}
+		if fc != nil {
+			p.applyAnnotations(fieldKey, field, fc, wrapper)
 		}
-		if len(fc.Default) > 0 {
-			fieldSchemaRef.Value.Default = fc.Default
+		wrapper.Type = ""
+		wrapper.Nullable = p.isNullable(fc, field, opts)
+		if reflect.DeepEqual(*wrapper, openapi3.Schema{}) {
+			return fieldSchemaRef
 		}
-		if len(fc.Enum) > 0 {
-			fieldSchemaRef.Value.Enum = fc.Enum
-		}
-		fieldSchemaRef.Value.Nullable = fc.Nullable
-		fieldSchemaRef.Value.Deprecated = fc.Deprecated
-		fieldSchemaRef.Value.Enum = fc.Enum
+		wrapper.AllOf = openapi3.SchemaRefs{fieldSchemaRef}
+		return openapi3.NewSchemaRef("", wrapper)
+	} else if obj := p.objectOf(field.Type); !overridden && (obj == nil || obj.Pkg() == nil) {
+		fieldSchemaRef.Value.Type = getOpenAPIFieldType(field.Type)
+	}
+
+	// Annotations take precedence over the constraints of the validation tags
+	p.applyValidation(fieldKey, field, fieldSchemaRef)
+	if p.isNullable(fc, field, opts) {
+		fieldSchemaRef.Value.Nullable = true
+	}
+
+	if fc != nil {
+		p.applyAnnotations(fieldKey, field, fc, fieldSchemaRef.Value)
 	}
 
-	return fieldSchemaRef, jsonTag
+	return fieldSchemaRef
+}
+
+// applyAnnotations sets the keywords annotated on the field to schema.
+func (p *Parser) applyAnnotations(key string, field *ast.Field, fc *fieldComment, schema *openapi3.Schema) {
+	if len(fc.Description) > 0 {
+		schema.Description = fc.Description
+	}
+	if len(fc.Format) > 0 {
+		schema.Format = fc.Format
+	}
+	schema.Deprecated = fc.Deprecated
+	if len(fc.Title) > 0 {
+		schema.Title = fc.Title
+	}
+	schema.ReadOnly = fc.ReadOnly
+	schema.WriteOnly = fc.WriteOnly
+	p.applyConstraints(key, field, fc, schema)
+	p.applyValues(key, field, fc, schema)
 }
 
 func (p *Parser) GetTypeSpec(t ast.Expr) *ast.TypeSpec {
+	if decl := p.lookupTypeDecl(p.objectOf(t)); decl != nil {
+		return decl.spec
+	}
+
 	switch t := t.(type) {
 	case *ast.StarExpr:
 		return p.GetTypeSpec(t.X)
@@ -199,7 +393,10 @@ func (p *Parser) GetTypeSpec(t ast.Expr) *ast.TypeSpec {
 		if ts, ok := p.typeMap[t.Name]; ok {
 			return ts
 		}
-		// Traverse the package AST to find the type declaration
+		if p.file == nil {
+			return nil
+		}
+		// Traverse the file AST to find the type declaration of files that were not loaded as a package
 		var ts *ast.TypeSpec
 		ast.Inspect(p.file, func(n ast.Node) bool {
 			switch n := n.(type) {
@@ -211,41 +408,183 @@ func (p *Parser) GetTypeSpec(t ast.Expr) *ast.TypeSpec {
 			}
 			return true // Continue traversal
 		})
-
-		// Update cache
-		p.typeMap[t.Name] = ts
 		return ts
 	}
 	return nil
 }
 
+// parseNamedType returns the OpenAPI schema for the named type obj. Structs are referenced as
+// components while any other type is inlined.
+func (p *Parser) parseNamedType(key string, obj *types.TypeName) *openapi3.SchemaRef {
+	if obj != nil {
+		if _, ok := obj.Type().(*types.TypeParam); ok {
+			return p.parseTypeParam(key, obj)
+		}
+	}
+
+	if schema := p.getTypeMapping(obj); schema != nil {
+		return openapi3.NewSchemaRef("", schema)
+	}
+
+	decl := p.lookupTypeDecl(obj)
+	if decl == nil {
+		p.logger.Debug("declaration not found for %s", key)
+		return nil
+	}
+
+	if values := p.getEnumValues(decl); len(values) > 0 {
+		// Named types with constants are components listing the constants as enum
+		name := p.registerTypeDecl(decl)
+		return &openapi3.SchemaRef{
+			Ref:   fmt.Sprintf("#/components/schemas/%s", name),
+			Value: p.createEnumSchema(name, decl, values),
+		}
+	}
+
+	if schema := p.getWireSchema(decl); schema != nil && !p.isComponent(decl) {
+		// Types that are not components are inlined as they are serialized
+		return openapi3.NewSchemaRef("", schema)
+	}
+
+	if isCollection(decl) && p.isComponent(decl) {
+		// Named collections annotated with openapi:schema are referenced instead of inlined
+		name := p.registerTypeDecl(decl)
+		return &openapi3.SchemaRef{
+			Ref:   fmt.Sprintf("#/components/schemas/%s", name),
+			Value: p.createCollectionSchema(name, decl),
+		}
+	}
+
+	if _, ok := decl.spec.Type.(*ast.InterfaceType); ok && decl.spec.TypeParams == nil {
+		// Interfaces are documented as the oneOf of their implementations
+		return p.parseInterfaceType(key, obj, decl)
+	}
+
+	if _, ok := decl.spec.Type.(*ast.StructType); !ok {
+		// Inlined types have no component to reference, so recursion cannot be expressed
+		if !p.startInline(key, decl) {
+			return nil
+		}
+		defer p.endInline(decl)
+		return p.ParseTypeExpr(key, decl.spec.Type)
+	}
+
+	name := p.registerTypeDecl(decl)
+	return &openapi3.SchemaRef{
+		Ref:   fmt.Sprintf("#/components/schemas/%s", name),
+		Value: p.createOpenAPISchema(name, decl.spec),
+	}
+}
+
+// isComponent reports whether decl is a struct or a named collection annotated with openapi:schema or
+// already registered as a component.
+func (p *Parser) isComponent(decl *typeDecl) bool {
+	if _, ok := decl.spec.Type.(*ast.StructType); !ok && !isCollection(decl) {
+		return false
+	}
+	if _, ok := p.schemaNames[decl.qualifiedName()]; ok {
+		return true
+	}
+	return parseStructComment(decl.spec.Name.Name, decl.doc).Schema
+}
+
+// startInline marks decl as being inlined in the schema key. It reports false with a diagnostic if decl
+// is already being inlined, i.e. the inlined type is recursive.
+func (p *Parser) startInline(key string, decl *typeDecl) bool {
+	qualifiedName := decl.qualifiedName()
+	if p.inlining[qualifiedName] {
+		p.report(&Diagnostic{
+			Severity:   SeverityError,
+			Message:    fmt.Sprintf("recursive type %s cannot be inlined in %s", decl.spec.Name.Name, key),
+			Suggestion: "declare it as a struct component with openapi:schema",
+		}, decl.spec.Pos())
+		return false
+	}
+	p.inlining[qualifiedName] = true
+	return true
+}
+
+func (p *Parser) endInline(decl *typeDecl) {
+	delete(p.inlining, decl.qualifiedName())
+}
+
+// registerTypeDecl registers decl as a component schema and returns the schema name. Types that are
+// referenced without an openapi:schema annotation are registered using their Go name, qualified with
+// the package name if another type already uses it.
+func (p *Parser) registerTypeDecl(decl *typeDecl) string {
+	qualifiedName := decl.qualifiedName()
+	if name, ok := p.schemaNames[qualifiedName]; ok {
+		return name
+	}
+
+	name := p.extractStructComments(decl.spec.Name.Name, decl.doc)
+	if name == nil {
+		p.logger.Debug("registering referenced type %s", qualifiedName)
+		c := &structComment{Schema: true, Name: p.componentName(decl)}
+		p.structComments[c.Name] = c
+		name = &c.Name
+	}
+
+	p.schemaNames[qualifiedName] = *name
+	p.typeMap[*name] = decl.spec
+	if st, ok := decl.spec.Type.(*ast.StructType); ok {
+		p.structMap[*name] = st
+	}
+	return *name
+}
+
+// componentName returns an unused component name for the unannotated type decl, e.g. `Error` or
+// `BError` if `a.Error` is already registered as `Error`.
+func (p *Parser) componentName(decl *typeDecl) string {
+	name := decl.spec.Name.Name
+	if _, ok := p.typeMap[name]; !ok {
+		return name
+	}
+	if decl.pkg != nil {
+		name = exported(decl.pkg.Name) + name
+	}
+	// Packages of the same name are numbered
+	for i, qualified := 2, name; ; i++ {
+		if _, ok := p.typeMap[qualified]; !ok {
+			p.logger.Debug("component %s is taken, registering %s as %s", decl.spec.Name.Name, decl.qualifiedName(), qualified)
+			return qualified
+		}
+		qualified = fmt.Sprintf("%s%d", name, i)
+	}
+}
+
 // ParseTypeExpr returns the OpenAPI schema for the given Go type expression.
 // Returns nil if the expression is not a valid type.
 // TODO: should search cache based on openapi:name tag instead of field name
 func (p *Parser) ParseTypeExpr(key string, expr ast.Expr) *openapi3.SchemaRef {
 	switch t := expr.(type) {
 	case *ast.Ident:
-		switch t.Name {
-		case "string", "error":
+		if t.Name == "error" {
 			return &openapi3.SchemaRef{Value: &openapi3.Schema{Type: "string"}}
-		case "int", "int32", "int64", "uint":
-			return &openapi3.SchemaRef{Value: &openapi3.Schema{Type: "integer", Format: t.Name}}
-		case "float32", "float64":
-			return &openapi3.SchemaRef{Value: &openapi3.Schema{Type: "number", Format: t.Name}}
-		case "bool":
-			return &openapi3.SchemaRef{Value: &openapi3.Schema{Type: "boolean"}}
-		case "[]byte":
-			return &openapi3.SchemaRef{Value: &openapi3.Schema{Type: "string", Format: "byte"}}
-		case "time.Time":
-			return &openapi3.SchemaRef{Value: &openapi3.Schema{Type: "string", Format: "date-time"}}
-		default:
-			ts := p.GetTypeSpec(t)
-			return &openapi3.SchemaRef{
-				Ref:   fmt.Sprintf("#/components/schemas/%s", key),
-				Value: p.createOpenAPISchema(key, ts),
+		}
+		obj := p.objectOf(t)
+		if obj == nil || obj.Pkg() == nil {
+			// Predeclared types, unless the name is shadowed by a declared type
+			if schema := getBasicSchema(t.Name); schema != nil {
+				return &openapi3.SchemaRef{Value: schema}
 			}
 		}
+		return p.parseNamedType(key, obj)
+	case *ast.SelectorExpr:
+		return p.parseNamedType(key, p.objectOf(t))
+	case *ast.IndexExpr:
+		return p.parseGenericType(key, t.X, []ast.Expr{t.Index})
+	case *ast.IndexListExpr:
+		return p.parseGenericType(key, t.X, t.Indices)
+	case *ast.StarExpr:
+		return p.ParseTypeExpr(key, t.X)
+	case *ast.StructType:
+		return p.parseAnonymousStruct(key, t)
 	case *ast.ArrayType:
+		if t.Len == nil && p.isByte(t.Elt) {
+			// Byte slices are serialized as base64 encoded strings
+			return &openapi3.SchemaRef{Value: &openapi3.Schema{Type: "string", Format: "byte"}}
+		}
 		itemsSchemaRef := p.ParseTypeExpr(key, t.Elt)
 		if itemsSchemaRef != nil {
 			return &openapi3.SchemaRef{
@@ -256,74 +595,182 @@ func (p *Parser) ParseTypeExpr(key string, expr ast.Expr) *openapi3.SchemaRef {
 			}
 		}
 		return nil
+	case *ast.MapType:
+		if !p.isStringKey(t.Key) {
+			p.warn(t.Key.Pos(), "", "map key of %s is not a string, the keys are serialized as JSON object keys", key)
+		}
+		valueSchemaRef := p.ParseTypeExpr(key, t.Value)
+		if valueSchemaRef == nil {
+			// Any value is allowed if the value type cannot be parsed
+			valueSchemaRef = openapi3.NewSchemaRef("", openapi3.NewSchema())
+		}
+		return &openapi3.SchemaRef{
+			Value: &openapi3.Schema{
+				Type:                 "object",
+				AdditionalProperties: openapi3.AdditionalProperties{Schema: valueSchemaRef},
+			},
+		}
 	}
 
 	return nil
 }
 
-func (p *Parser) extractStructComments(name string, cg *ast.CommentGroup) *string {
-	if cg == nil {
-		p.logger.Debug("no comments found for %s", name)
-		return nil
+// isByte reports whether expr is the byte type.
+func (p *Parser) isByte(expr ast.Expr) bool {
+	if t := p.typeOf(expr); t != nil {
+		basic, ok := t.Underlying().(*types.Basic)
+		return ok && basic.Kind() == types.Byte
 	}
+	ident, ok := expr.(*ast.Ident)
+	return ok && (ident.Name == "byte" || ident.Name == "uint8")
+}
 
-	c := &structComment{}
-	for _, comment := range cg.List {
-		text := strings.TrimSpace(strings.TrimLeft(comment.Text, "/"))
-		if strings.Contains(text, "openapi:schema") {
-			c.Schema = true
-			c.Name = strings.Split(strings.TrimSpace(strings.TrimPrefix(text, "openapi:schema")), " ")[0]
-		} else if strings.HasPrefix(text, "openapi:xml") {
-			c.XML.Name = strings.Trim(strings.TrimSpace(strings.TrimPrefix(text, "openapi:xml")), "\"")
+// isStringKey reports whether the map key expr has an underlying string type.
+func (p *Parser) isStringKey(expr ast.Expr) bool {
+	if t := p.typeOf(expr); t != nil {
+		basic, ok := t.Underlying().(*types.Basic)
+		return ok && basic.Info()&types.IsString != 0
+	}
+	ident, ok := expr.(*ast.Ident)
+	return ok && ident.Name == "string"
+}
+
+// isStruct reports whether expr is a struct or a pointer to a struct.
+func (p *Parser) isStruct(expr ast.Expr) bool {
+	if t := p.typeOf(expr); t != nil {
+		if ptr, ok := t.(*types.Pointer); ok {
+			t = ptr.Elem()
 		}
+		_, ok := t.Underlying().(*types.Struct)
+		return ok
 	}
+	decl := p.lookupTypeDecl(p.objectOf(expr))
+	if decl == nil {
+		return false
+	}
+	_, ok := decl.spec.Type.(*ast.StructType)
+	return ok
+}
 
-	if !c.Schema {
+// isQuotable reports whether the string option of the json tag applies to expr, i.e. expr is a string,
+// number or boolean, or a pointer to one of them.
+func (p *Parser) isQuotable(expr ast.Expr) bool {
+	t := p.typeOf(expr)
+	if t == nil {
+		switch getOpenAPIFieldType(expr) {
+		case "string", "integer", "number", "boolean":
+			return true
+		}
+		return false
+	}
+	if ptr, ok := t.(*types.Pointer); ok {
+		t = ptr.Elem()
+	}
+	basic, ok := t.Underlying().(*types.Basic)
+	return ok && basic.Info()&(types.IsString|types.IsNumeric|types.IsBoolean) != 0 && basic.Info()&types.IsComplex == 0
+}
+
+func (p *Parser) extractStructComments(name string, cg *ast.CommentGroup) *string {
+	if cg == nil {
+		p.logger.Debug("no comments found for %s", name)
 		return nil
 	}
 
-	if len(c.Name) == 0 {
-		c.Name = name
+	c := parseStructComment(name, cg)
+	if !c.Schema {
+		return nil
 	}
 
 	if _, ok := p.typeMap[c.Name]; ok {
-		p.logger.Fatal("duplicate struct `%s` are not supported", c.Name)
+		p.report(&Diagnostic{
+			Severity:   SeverityError,
+			Directive:  "openapi:schema",
+			Message:    fmt.Sprintf("duplicate schema %s, the annotation of %s is ignored", c.Name, name),
+			Suggestion: "name the schema with openapi:schema <Name>",
+		}, cg.Pos())
+		return nil
 	}
 	p.structComments[c.Name] = c
 	return &c.Name
 }
 
+// parseStructComment parses the openapi annotations of the type declaration name.
+func parseStructComment(name string, cg *ast.CommentGroup) *structComment {
+	c := &structComment{}
+	for _, d := range parseDirectives(cg) {
+		switch d.Name {
+		case "schema":
+			c.Schema = true
+			c.Name = d.arg(0)
+		case "description":
+			c.Description = d.value()
+		case "xml":
+			c.XML.Name = d.value()
+		case "discriminator":
+			c.Discriminator = d.Text
+		case "yaml":
+			c.Fragment = d
+		}
+	}
+
+	if len(c.Name) == 0 {
+		c.Name = name
+	}
+	return c
+}
+
 func (p *Parser) extractFieldComments(schemaName string, name string, cg *ast.CommentGroup) *string {
 	if cg == nil {
 		cg = &ast.CommentGroup{List: []*ast.Comment{}}
 	}
 
-	c := &fieldComment{}
-	for _, comment := range cg.List {
-		text := strings.TrimSpace(strings.TrimLeft(comment.Text, "/"))
-		if strings.HasPrefix(text, "openapi:description") {
-			c.Description = strings.Trim(strings.TrimSpace(strings.TrimPrefix(text, "openapi:description")), "\"")
-		} else if strings.HasPrefix(text, "openapi:example") {
-			c.Example = strings.Trim(strings.TrimSpace(strings.TrimPrefix(text, "openapi:example")), "\"")
-		} else if strings.Contains(text, "openapi:deprecated") {
+	c := &fieldComment{Constraints: map[string]string{}, Positions: map[string]token.Pos{}}
+	for _, d := range parseDirectives(cg) {
+		c.Positions[d.Name] = d.Pos
+		switch d.Name {
+		case "description":
+			c.Description = d.value()
+		case "example":
+			c.Example = d.value()
+		case "deprecated":
 			c.Deprecated = true
-		} else if strings.HasPrefix(text, "openapi:operationID") {
-			c.Nullable = true
-		} else if strings.HasPrefix(text, "openapi:format") {
-			c.Format = strings.Trim(strings.TrimSpace(strings.TrimPrefix(text, "openapi:format")), "\"")
-		} else if strings.HasPrefix(text, "openapi:name") {
-			c.Name = strings.Trim(strings.TrimSpace(strings.TrimPrefix(text, "openapi:name")), "\"")
-		} else if strings.HasPrefix(text, "openapi:default") {
-			c.Default = strings.Trim(strings.TrimSpace(strings.TrimPrefix(text, "openapi:default")), "\"")
-		} else if strings.HasPrefix(text, "openapi:enum") {
-			enums := strings.Split(strings.Trim(strings.TrimSpace(strings.TrimPrefix(text, "openapi:enum")), "\""), ",")
-			for _, enum := range enums {
-				c.Enum = append(c.Enum, enum)
+		case "nullable":
+			nullable := d.arg(0) != "false"
+			c.Nullable = &nullable
+		case "required":
+			required := d.arg(0) != "false"
+			c.Required = &required
+		case "format":
+			c.Format = d.value()
+		case "name":
+			c.Name = d.value()
+		case "default":
+			c.Default = d.value()
+		case "enum":
+			for _, enum := range strings.Split(d.value(), ",") {
+				c.Enum = append(c.Enum, strings.TrimSpace(enum))
 			}
-		} else if strings.HasPrefix(text, "openapi:oneOf") {
-			oneOfs := strings.Split(strings.Trim(strings.TrimSpace(strings.TrimPrefix(text, "openapi:oneOf")), "\""), " ")
-			for _, oneOfType := range oneOfs {
-				c.OneOf = append(c.OneOf, oneOfType)
+		case "oneOf":
+			c.OneOf = append(c.OneOf, d.Args...)
+		case "anyOf":
+			c.AnyOf = d.Args
+		case "allOf":
+			c.AllOf = d.Args
+		case "discriminator":
+			c.Discriminator = d.Text
+		case "type":
+			c.Type = d.Text
+		case "title":
+			c.Title = d.value()
+		case "readOnly":
+			c.ReadOnly = true
+		case "writeOnly":
+			c.WriteOnly = true
+		case "yaml":
+			c.Fragment = d
+		default:
+			if isConstraint(d.Name) {
+				c.Constraints[d.Name] = d.value()
 			}
 		}
 	}
@@ -0,0 +1,1388 @@
+package scan
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"gopkg.in/yaml.v3"
+)
+
+func getTestProperty(t *testing.T, spec *openapi3.T, schema, property string) *openapi3.SchemaRef {
+	t.Helper()
+	schemaRef, ok := spec.Components.Schemas[schema]
+	if !ok {
+		t.Fatalf("schema %s not found", schema)
+	}
+	propertyRef, ok := schemaRef.Value.Properties[property]
+	if !ok {
+		t.Fatalf("property %s not found in %s", property, schema)
+	}
+	return propertyRef
+}
+
+// getTestRef returns the reference of schemaRef, or the reference wrapped in allOf next to the annotations of a field.
+func getTestRef(schemaRef *openapi3.SchemaRef) *openapi3.SchemaRef {
+	if len(schemaRef.Ref) == 0 && schemaRef.Value != nil && len(schemaRef.Value.AllOf) == 1 && len(schemaRef.Value.Type) == 0 {
+		return schemaRef.Value.AllOf[0]
+	}
+	return schemaRef
+}
+
+func TestParser_ParseTypeExpr_Map(t *testing.T) {
+	spec := getTestSpec(t, "testdata/models")
+
+	tests := []struct {
+		name      string
+		property  string
+		wantRef   string
+		wantValue string
+	}{
+		{
+			name:      "map of strings",
+			property:  "labels",
+			wantValue: "string",
+		},
+		{
+			name:     "map of structs",
+			property: "pets",
+			wantRef:  "#/components/schemas/Pet",
+		},
+		{
+			name:      "map with integer keys",
+			property:  "counters",
+			wantValue: "integer",
+		},
+		{
+			name:      "named map type",
+			property:  "annotations",
+			wantValue: "string",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := getTestProperty(t, spec, "Config", tt.property)
+			if got.Value.Type != "object" {
+				t.Errorf("type = %s, want object", got.Value.Type)
+			}
+			additionalProperties := got.Value.AdditionalProperties.Schema
+			if additionalProperties == nil {
+				t.Fatalf("additionalProperties not set")
+			}
+			if additionalProperties.Ref != tt.wantRef {
+				t.Errorf("additionalProperties ref = %s, want %s", additionalProperties.Ref, tt.wantRef)
+			}
+			if len(tt.wantValue) > 0 && additionalProperties.Value.Type != tt.wantValue {
+				t.Errorf("additionalProperties type = %s, want %s", additionalProperties.Value.Type, tt.wantValue)
+			}
+		})
+	}
+
+	response := spec.Paths["/annotations"].Get.Responses.Get(200)
+	schema := response.Value.Content.Get("application/json").Schema.Value
+	if schema.Type != "object" || schema.AdditionalProperties.Schema == nil {
+		t.Errorf("response 200 of getAnnotations is not a map schema")
+	}
+}
+
+func TestParser_createOpenAPISchema_Embedded(t *testing.T) {
+	spec := getTestSpec(t, "testdata/models")
+
+	tests := []struct {
+		name            string
+		property        string
+		wantDescription string
+	}{
+		{
+			name:            "promoted field",
+			property:        "id",
+			wantDescription: "ID of the resource",
+		},
+		{
+			name:            "promoted field shadowed by own field",
+			property:        "name",
+			wantDescription: "Display name of the resource",
+		},
+		{
+			name:            "own field",
+			property:        "owner",
+			wantDescription: "Owner of the resource",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := getTestProperty(t, spec, "Resource", tt.property)
+			if got.Value.Description != tt.wantDescription {
+				t.Errorf("description = %s, want %s", got.Value.Description, tt.wantDescription)
+			}
+		})
+	}
+
+	if _, ok := spec.Components.Schemas["Metadata"]; ok {
+		t.Errorf("flattened schema Metadata should not be a component")
+	}
+
+	versioned := spec.Components.Schemas["Versioned"].Value
+	if len(versioned.AllOf) != 2 {
+		t.Fatalf("Versioned allOf = %v, want 2 schemas", versioned.AllOf)
+	}
+	if versioned.AllOf[0].Ref != "#/components/schemas/BaseModel" {
+		t.Errorf("Versioned allOf[0] ref = %s, want #/components/schemas/BaseModel", versioned.AllOf[0].Ref)
+	}
+	if _, ok := versioned.AllOf[1].Value.Properties["label"]; !ok {
+		t.Errorf("Versioned allOf[1] does not contain the property label")
+	}
+
+	// The fields promoted by an embedded generic struct are instantiated with its type arguments
+	feed := spec.Components.Schemas["PetFeed"].Value
+	if items := getTestProperty(t, spec, "PetFeed", "items").Value.Items; items == nil || items.Ref != "#/components/schemas/Pet" {
+		t.Errorf("PetFeed items = %v, want ref #/components/schemas/Pet", items)
+	}
+	if got := sortedKeys(feed.Properties); !reflect.DeepEqual(got, []string{"items", "next", "title"}) {
+		t.Errorf("PetFeed properties = %v, want [items next title]", got)
+	}
+}
+
+func TestParser_ParseTypeExpr_Generic(t *testing.T) {
+	tests := []struct {
+		name         string
+		naming       GenericNaming
+		property     string
+		wantRef      string
+		wantItemsRef string
+	}{
+		{
+			name:         "single type argument",
+			naming:       GenericNamingConcat,
+			property:     "pets",
+			wantRef:      "#/components/schemas/PagePet",
+			wantItemsRef: "#/components/schemas/Pet",
+		},
+		{
+			name:     "nested type arguments",
+			naming:   GenericNamingConcat,
+			property: "wrapped",
+			wantRef:  "#/components/schemas/EnvelopePagePetString",
+		},
+		{
+			name:         "underscore naming",
+			naming:       GenericNamingUnderscore,
+			property:     "pets",
+			wantRef:      "#/components/schemas/Page_Pet",
+			wantItemsRef: "#/components/schemas/Pet",
+		},
+		{
+			name:     "of naming",
+			naming:   GenericNamingOf,
+			property: "wrapped",
+			wantRef:  "#/components/schemas/EnvelopeOfPageOfPetAndString",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spec := getTestSpec(t, "testdata/models")
+			if tt.naming != GenericNamingConcat {
+				var err error
+				spec, err = NewParser(NewLogger(LogLevelError)).WithGenericNaming(tt.naming).GetSpec([]string{"testdata/models"})
+				if err != nil {
+					t.Fatalf("GetSpec() error = %v", err)
+				}
+			}
+
+			got := getTestRef(getTestProperty(t, spec, "PetList", tt.property))
+			if got.Ref != tt.wantRef {
+				t.Errorf("ref = %s, want %s", got.Ref, tt.wantRef)
+			}
+			if len(tt.wantItemsRef) > 0 {
+				items := got.Value.Properties["items"].Value.Items
+				if items == nil || items.Ref != tt.wantItemsRef {
+					t.Errorf("items = %v, want ref %s", items, tt.wantItemsRef)
+				}
+			}
+		})
+	}
+
+	spec := getTestSpec(t, "testdata/models")
+	if _, ok := spec.Components.Schemas["Page"]; ok {
+		t.Errorf("generic declaration Page should not be a component")
+	}
+	envelope := spec.Components.Schemas["EnvelopePagePetString"].Value
+	if ref := getTestRef(envelope.Properties["data"]).Ref; ref != "#/components/schemas/PagePet" {
+		t.Errorf("EnvelopePagePetString data ref = %s, want #/components/schemas/PagePet", ref)
+	}
+	response := spec.Paths["/pets"].Get.Responses.Get(200)
+	if response.Value.Content.Get("application/json").Schema.Value != spec.Components.Schemas["PagePet"].Value {
+		t.Errorf("response 200 of listPets does not use PagePet")
+	}
+}
+
+func TestParser_ParseTypeExpr_TypeMappings(t *testing.T) {
+	parser := NewParser(NewLogger(LogLevelError))
+	if err := parser.LoadTypeMappings("testdata/models/types.yaml"); err != nil {
+		t.Fatalf("LoadTypeMappings() error = %v", err)
+	}
+	spec, err := parser.GetSpec([]string{"testdata/models"})
+	if err != nil {
+		t.Fatalf("GetSpec() error = %v", err)
+	}
+
+	tests := []struct {
+		name        string
+		property    string
+		wantType    string
+		wantFormat  string
+		wantPattern string
+	}{
+		{
+			name:       "time",
+			property:   "createdAt",
+			wantType:   "string",
+			wantFormat: "date-time",
+		},
+		{
+			name:       "pointer to duration",
+			property:   "timeout",
+			wantType:   "integer",
+			wantFormat: "int64",
+		},
+		{
+			name:     "raw message",
+			property: "payload",
+		},
+		{
+			name:     "ip",
+			property: "source",
+			wantType: "string",
+		},
+		{
+			name:       "byte slice",
+			property:   "checksum",
+			wantType:   "string",
+			wantFormat: "byte",
+		},
+		{
+			name:        "user mapping",
+			property:    "price",
+			wantType:    "string",
+			wantPattern: `^\d+\.\d{2}$`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := getTestProperty(t, spec, "Event", tt.property)
+			if got.Value.Type != tt.wantType || got.Value.Format != tt.wantFormat || got.Value.Pattern != tt.wantPattern {
+				t.Errorf("schema = %s/%s/%s, want %s/%s/%s", got.Value.Type, got.Value.Format, got.Value.Pattern,
+					tt.wantType, tt.wantFormat, tt.wantPattern)
+			}
+		})
+	}
+
+	if _, ok := spec.Components.Schemas["Money"]; ok {
+		t.Errorf("mapped type Money should not be a component")
+	}
+	if defaultTypeMappings["time.Time"].Description != "" {
+		t.Errorf("field annotations must not modify the default type mappings")
+	}
+}
+
+func TestParser_createEnumSchema(t *testing.T) {
+	spec := getTestSpec(t, "testdata/models")
+
+	tests := []struct {
+		name             string
+		schema           string
+		property         string
+		wantRef          string
+		wantType         string
+		wantEnum         []interface{}
+		wantVarNames     []interface{}
+		wantDescriptions []interface{}
+	}{
+		{
+			name:             "string constants",
+			schema:           "Pet",
+			property:         "status",
+			wantRef:          "#/components/schemas/PetStatus",
+			wantType:         "string",
+			wantEnum:         []interface{}{"available", "sold"},
+			wantVarNames:     []interface{}{"PetStatusAvailable", "PetStatusSold"},
+			wantDescriptions: []interface{}{"The pet is available for adoption", "The pet was adopted"},
+		},
+		{
+			name:             "iota constants",
+			schema:           "Pet",
+			property:         "size",
+			wantRef:          "#/components/schemas/Size",
+			wantType:         "integer",
+			wantEnum:         []interface{}{int64(0), int64(1), int64(2)},
+			wantVarNames:     []interface{}{"SizeSmall", "SizeMedium", "SizeLarge"},
+			wantDescriptions: []interface{}{"Fits in a bag", "Fits in a car", ""},
+		},
+		{
+			name:             "String switch of a text marshaler",
+			schema:           "Lamp",
+			property:         "color",
+			wantRef:          "#/components/schemas/Color",
+			wantType:         "string",
+			wantEnum:         []interface{}{"red", "green"},
+			wantVarNames:     []interface{}{"ColorRed", "ColorGreen"},
+			wantDescriptions: []interface{}{"The color of stop signs", "The color of grass"},
+		},
+		{
+			name:         "String map of a text marshaler",
+			schema:       "Lamp",
+			property:     "priority",
+			wantRef:      "#/components/schemas/Priority",
+			wantType:     "string",
+			wantEnum:     []interface{}{"low", "high"},
+			wantVarNames: []interface{}{"PriorityLow", "PriorityHigh"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := getTestRef(getTestProperty(t, spec, tt.schema, tt.property))
+			if got.Ref != tt.wantRef {
+				t.Fatalf("ref = %s, want %s", got.Ref, tt.wantRef)
+			}
+			if got.Value.Type != tt.wantType {
+				t.Errorf("type = %s, want %s", got.Value.Type, tt.wantType)
+			}
+			if !reflect.DeepEqual(got.Value.Enum, tt.wantEnum) {
+				t.Errorf("enum = %v, want %v", got.Value.Enum, tt.wantEnum)
+			}
+			if !reflect.DeepEqual(got.Value.Extensions["x-enum-varnames"], tt.wantVarNames) {
+				t.Errorf("x-enum-varnames = %v, want %v", got.Value.Extensions["x-enum-varnames"], tt.wantVarNames)
+			}
+			descriptions, ok := got.Value.Extensions["x-enum-descriptions"]
+			if ok != (tt.wantDescriptions != nil) || ok && !reflect.DeepEqual(descriptions, tt.wantDescriptions) {
+				t.Errorf("x-enum-descriptions = %v, want %v", descriptions, tt.wantDescriptions)
+			}
+		})
+	}
+
+	// The String method of a text marshaler declared in a dependency is resolved too
+	shared := getTestSpec(t, "testdata/shared/api")
+	if ref := getTestRef(getTestProperty(t, shared, "Event", "level")).Ref; ref != "#/components/schemas/Level" {
+		t.Fatalf("Event level ref = %s, want #/components/schemas/Level", ref)
+	}
+	if enum := shared.Components.Schemas["Level"].Value.Enum; !reflect.DeepEqual(enum, []interface{}{"low", "high"}) {
+		t.Errorf("Level enum = %v, want [low high]", enum)
+	}
+}
+
+func TestParser_createOpenAPISchema_Recursive(t *testing.T) {
+	spec := getTestSpec(t, "testdata/models")
+
+	tests := []struct {
+		name         string
+		schema       string
+		property     string
+		wantItemsRef string
+	}{
+		{
+			name:         "self reference",
+			schema:       "TreeNode",
+			property:     "children",
+			wantItemsRef: "#/components/schemas/TreeNode",
+		},
+		{
+			name:         "mutual reference",
+			schema:       "Thread",
+			property:     "comments",
+			wantItemsRef: "#/components/schemas/Comment",
+		},
+		{
+			name:         "generic self reference",
+			schema:       "NodeString",
+			property:     "children",
+			wantItemsRef: "#/components/schemas/NodeString",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := getTestProperty(t, spec, tt.schema, tt.property)
+			items := got.Value.Items
+			if items == nil || items.Ref != tt.wantItemsRef {
+				t.Fatalf("items = %v, want ref %s", items, tt.wantItemsRef)
+			}
+		})
+	}
+
+	treeNode := spec.Components.Schemas["TreeNode"].Value
+	if treeNode.Properties["children"].Value.Items.Value != treeNode {
+		t.Errorf("recursive reference of TreeNode does not resolve to the component")
+	}
+
+	tree := getTestProperty(t, spec, "Folder", "tree")
+	if tree.Value.AdditionalProperties.Schema == nil || !tree.Value.AdditionalProperties.Schema.Value.IsEmpty() {
+		t.Errorf("recursive inlined type should stop at an empty schema")
+	}
+}
+
+func TestParser_ParseTypeExpr_AnonymousStruct(t *testing.T) {
+	t.Run("inline", func(t *testing.T) {
+		spec := getTestSpec(t, "testdata/models")
+
+		options := getTestProperty(t, spec, "CreateOrderRequest", "options")
+		if options.Ref != "" || options.Value.Type != "object" || options.Value.Description != "Options of the order" {
+			t.Fatalf("options = %+v, want an inline object with description", options)
+		}
+		if got := options.Value.Properties["giftWrap"]; got == nil || got.Value.Type != "boolean" {
+			t.Errorf("options.giftWrap = %v, want boolean", got)
+		}
+		delivery := options.Value.Properties["delivery"]
+		if delivery == nil || delivery.Value.Properties["from"] == nil {
+			t.Errorf("options.delivery = %v, want nested object with from", delivery)
+		}
+
+		lines := getTestProperty(t, spec, "CreateOrderRequest", "lines")
+		if lines.Value.Items == nil || lines.Value.Items.Value.Properties["quantity"] == nil {
+			t.Errorf("lines.items = %v, want object with quantity", lines.Value.Items)
+		}
+
+		labels := getTestProperty(t, spec, "CreateOrderRequest", "labels")
+		if labels.Value.AdditionalProperties.Schema == nil || labels.Value.AdditionalProperties.Schema.Value.Properties["color"] == nil {
+			t.Errorf("labels.additionalProperties = %v, want object with color", labels.Value.AdditionalProperties.Schema)
+		}
+	})
+
+	t.Run("hoisted", func(t *testing.T) {
+		spec, err := NewParser(NewLogger(LogLevelError)).WithHoistAnonymous(true).GetSpec([]string{"testdata/models"})
+		if err != nil {
+			t.Fatalf("GetSpec() error = %v", err)
+		}
+
+		tests := []struct {
+			name    string
+			ref     *openapi3.SchemaRef
+			wantRef string
+		}{
+			{
+				name:    "field",
+				ref:     getTestRef(getTestProperty(t, spec, "CreateOrderRequest", "options")),
+				wantRef: "#/components/schemas/CreateOrderRequestOptions",
+			},
+			{
+				name:    "nested field",
+				ref:     getTestRef(getTestProperty(t, spec, "CreateOrderRequestOptions", "delivery")),
+				wantRef: "#/components/schemas/CreateOrderRequestOptionsDelivery",
+			},
+			{
+				name:    "slice",
+				ref:     getTestProperty(t, spec, "CreateOrderRequest", "lines").Value.Items,
+				wantRef: "#/components/schemas/CreateOrderRequestLines",
+			},
+			{
+				name:    "map",
+				ref:     getTestProperty(t, spec, "CreateOrderRequest", "labels").Value.AdditionalProperties.Schema,
+				wantRef: "#/components/schemas/CreateOrderRequestLabels",
+			},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				if tt.ref == nil || tt.ref.Ref != tt.wantRef {
+					t.Errorf("ref = %v, want %s", tt.ref, tt.wantRef)
+				}
+			})
+		}
+	})
+}
+
+func TestParser_parseStructFields_JSONNames(t *testing.T) {
+	spec := getTestSpec(t, "testdata/models")
+
+	account, ok := spec.Components.Schemas["Account"]
+	if !ok {
+		t.Fatalf("schema Account not found")
+	}
+
+	want := map[string]string{
+		"ID":      "string",
+		"First":   "string",
+		"Last":    "string",
+		"balance": "string",
+		"-":       "string",
+	}
+	if len(account.Value.Properties) != len(want) {
+		t.Errorf("properties = %v, want %v", sortedKeys(account.Value.Properties), want)
+	}
+	for name, wantType := range want {
+		property, ok := account.Value.Properties[name]
+		if !ok {
+			t.Errorf("property %s not found", name)
+			continue
+		}
+		if property.Value.Type != wantType {
+			t.Errorf("property %s type = %s, want %s", name, property.Value.Type, wantType)
+		}
+	}
+
+	page := getTestProperty(t, spec, "PagedPets", "page")
+	if ref := getTestRef(page).Ref; ref != "#/components/schemas/PagePet" {
+		t.Errorf("PagedPets page ref = %s, want #/components/schemas/PagePet", ref)
+	}
+	if page.Value.Description != "Embedded generic struct named by its json tag" {
+		t.Errorf("PagedPets page description = %s, want Embedded generic struct named by its json tag", page.Value.Description)
+	}
+}
+
+func TestParser_parseStructFields_Required(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy RequiredPolicy
+		want   []string
+	}{
+		{
+			name:   "explicit",
+			policy: RequiredPolicyExplicit,
+			want:   []string{"address", "weight", "tracking"},
+		},
+		{
+			name:   "omitempty",
+			policy: RequiredPolicyOmitEmpty,
+			want:   []string{"id", "carrier", "address", "weight", "tracking"},
+		},
+		{
+			name:   "pointer",
+			policy: RequiredPolicyPointer,
+			want:   []string{"id", "address", "weight", "tracking"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spec := getTestSpec(t, "testdata/models")
+			if tt.policy != RequiredPolicyExplicit {
+				var err error
+				spec, err = NewParser(NewLogger(LogLevelError)).WithRequiredPolicy(tt.policy).GetSpec([]string{"testdata/models"})
+				if err != nil {
+					t.Fatalf("GetSpec() error = %v", err)
+				}
+			}
+			shipment, ok := spec.Components.Schemas["Shipment"]
+			if !ok {
+				t.Fatalf("schema Shipment not found")
+			}
+			if !reflect.DeepEqual(shipment.Value.Required, tt.want) {
+				t.Errorf("required = %v, want %v", shipment.Value.Required, tt.want)
+			}
+		})
+	}
+}
+
+func TestParser_applyValidation(t *testing.T) {
+	spec := getTestSpec(t, "testdata/models")
+
+	floatPtr := func(v float64) *float64 { return &v }
+	uintPtr := func(v uint64) *uint64 { return &v }
+
+	tests := []struct {
+		name     string
+		property string
+		want     func(schema *openapi3.Schema) bool
+	}{
+		{
+			name:     "string length and pattern",
+			property: "username",
+			want: func(schema *openapi3.Schema) bool {
+				return schema.MinLength == 1 && reflect.DeepEqual(schema.MaxLength, uintPtr(64)) && schema.Pattern == `^[a-zA-Z0-9]+$`
+			},
+		},
+		{
+			name:     "format",
+			property: "email",
+			want: func(schema *openapi3.Schema) bool {
+				return schema.Format == "email"
+			},
+		},
+		{
+			name:     "binding enum",
+			property: "kind",
+			want: func(schema *openapi3.Schema) bool {
+				return reflect.DeepEqual(schema.Enum, []interface{}{"cat", "dog"})
+			},
+		},
+		{
+			name:     "uuid",
+			property: "requestId",
+			want: func(schema *openapi3.Schema) bool {
+				return schema.Format == "uuid"
+			},
+		},
+		{
+			name:     "exclusive bounds",
+			property: "age",
+			want: func(schema *openapi3.Schema) bool {
+				return reflect.DeepEqual(schema.Min, floatPtr(0)) && schema.ExclusiveMin &&
+					reflect.DeepEqual(schema.Max, floatPtr(130)) && !schema.ExclusiveMax
+			},
+		},
+		{
+			name:     "items",
+			property: "tags",
+			want: func(schema *openapi3.Schema) bool {
+				return reflect.DeepEqual(schema.MaxItems, uintPtr(10)) && schema.UniqueItems && schema.Items.Value.MinLength == 2
+			},
+		},
+		{
+			name:     "unmapped rules",
+			property: "referrer",
+			want: func(schema *openapi3.Schema) bool {
+				return schema.Type == "string" && schema.MinLength == 0 && len(schema.Pattern) == 0
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := getTestProperty(t, spec, "Signup", tt.property)
+			if !tt.want(got.Value) {
+				t.Errorf("property %s = %+v", tt.property, got.Value)
+			}
+		})
+	}
+
+	if required := spec.Components.Schemas["Signup"].Value.Required; !reflect.DeepEqual(required, []string{"username", "email"}) {
+		t.Errorf("required = %v, want [username email]", required)
+	}
+}
+
+func TestParser_applyConstraints(t *testing.T) {
+	spec := getTestSpec(t, "testdata/models")
+
+	floatPtr := func(v float64) *float64 { return &v }
+	uintPtr := func(v uint64) *uint64 { return &v }
+
+	tests := []struct {
+		name     string
+		property string
+		want     func(schema *openapi3.Schema) bool
+	}{
+		{
+			name:     "title and readOnly",
+			property: "id",
+			want: func(schema *openapi3.Schema) bool {
+				return schema.Title == "Product ID" && schema.ReadOnly && !schema.WriteOnly
+			},
+		},
+		{
+			name:     "integer bounds",
+			property: "quantity",
+			want: func(schema *openapi3.Schema) bool {
+				return reflect.DeepEqual(schema.Min, floatPtr(1)) && reflect.DeepEqual(schema.Max, floatPtr(100)) &&
+					reflect.DeepEqual(schema.MultipleOf, floatPtr(5))
+			},
+		},
+		{
+			name:     "number bounds",
+			property: "price",
+			want: func(schema *openapi3.Schema) bool {
+				return reflect.DeepEqual(schema.Min, floatPtr(0)) && schema.ExclusiveMin && reflect.DeepEqual(schema.Max, floatPtr(9999.99))
+			},
+		},
+		{
+			name:     "string",
+			property: "code",
+			want: func(schema *openapi3.Schema) bool {
+				return schema.MinLength == 3 && reflect.DeepEqual(schema.MaxLength, uintPtr(10)) && schema.Pattern == "^[A-Z]+$"
+			},
+		},
+		{
+			name:     "array",
+			property: "tags",
+			want: func(schema *openapi3.Schema) bool {
+				return schema.MinItems == 1 && reflect.DeepEqual(schema.MaxItems, uintPtr(5)) && schema.UniqueItems
+			},
+		},
+		{
+			name:     "constraint of another type is skipped",
+			property: "secret",
+			want: func(schema *openapi3.Schema) bool {
+				return schema.WriteOnly && schema.Min == nil && schema.MinLength == 2
+			},
+		},
+		{
+			name:     "fraction for integer is skipped",
+			property: "count",
+			want: func(schema *openapi3.Schema) bool {
+				return schema.Min == nil
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := getTestProperty(t, spec, "Product", tt.property)
+			if !tt.want(got.Value) {
+				t.Errorf("property %s = %+v", tt.property, got.Value)
+			}
+		})
+	}
+}
+
+func TestParser_applyValues(t *testing.T) {
+	spec := getTestSpec(t, "testdata/models")
+
+	tests := []struct {
+		name        string
+		property    string
+		wantExample interface{}
+		wantDefault interface{}
+		wantEnum    []interface{}
+	}{
+		{
+			name:        "integer",
+			property:    "rooms",
+			wantExample: int64(3),
+			wantDefault: int64(1),
+			wantEnum:    []interface{}{int64(1), int64(2), int64(3)},
+		},
+		{
+			name:        "number",
+			property:    "rent",
+			wantExample: 99.5,
+		},
+		{
+			name:        "boolean",
+			property:    "furnished",
+			wantExample: true,
+			wantDefault: false,
+		},
+		{
+			name:        "array",
+			property:    "features",
+			wantExample: []interface{}{"pool", "garden"},
+		},
+		{
+			name:        "object",
+			property:    "extra",
+			wantExample: map[string]interface{}{"floor": float64(2)},
+		},
+		{
+			name:     "invalid value is skipped",
+			property: "floors",
+		},
+		{
+			name:        "string",
+			property:    "street",
+			wantExample: "1",
+		},
+		{
+			name:        "reference",
+			property:    "roomSize",
+			wantExample: int64(2),
+			wantDefault: int64(1),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := getTestProperty(t, spec, "Listing", tt.property)
+			if !reflect.DeepEqual(got.Value.Example, tt.wantExample) {
+				t.Errorf("example = %#v, want %#v", got.Value.Example, tt.wantExample)
+			}
+			if !reflect.DeepEqual(got.Value.Default, tt.wantDefault) {
+				t.Errorf("default = %#v, want %#v", got.Value.Default, tt.wantDefault)
+			}
+			if tt.wantEnum != nil && !reflect.DeepEqual(got.Value.Enum, tt.wantEnum) {
+				t.Errorf("enum = %#v, want %#v", got.Value.Enum, tt.wantEnum)
+			}
+		})
+	}
+
+	features := getTestProperty(t, spec, "Listing", "features")
+	if want := []interface{}{"pool", "garden", "garage"}; !reflect.DeepEqual(features.Value.Items.Value.Enum, want) {
+		t.Errorf("items enum = %v, want %v", features.Value.Items.Value.Enum, want)
+	}
+
+	// The annotations of a reference are set next to it in allOf, leaving the component unchanged
+	roomSize := getTestProperty(t, spec, "Listing", "roomSize").Value
+	if len(roomSize.AllOf) != 1 || roomSize.AllOf[0].Ref != "#/components/schemas/Size" {
+		t.Fatalf("roomSize allOf = %v, want ref #/components/schemas/Size", roomSize.AllOf)
+	}
+	if roomSize.Description != "Size of the rooms" || roomSize.Title != "Room size" || roomSize.Max == nil || *roomSize.Max != 2 || len(roomSize.Type) > 0 {
+		t.Errorf("roomSize = %+v, want description, title and maximum without type", roomSize)
+	}
+	if size := spec.Components.Schemas["Size"].Value; size.Example != nil || size.Default != nil || size.Max != nil || len(size.Description) > 0 {
+		t.Errorf("Size = %+v, want the component without the annotations of roomSize", size)
+	}
+}
+
+func TestParser_addComposition(t *testing.T) {
+	spec := getTestSpec(t, "testdata/models")
+
+	refs := func(refs openapi3.SchemaRefs) []string {
+		var names []string
+		for _, ref := range refs {
+			names = append(names, ref.Ref)
+		}
+		return names
+	}
+
+	resident := getTestProperty(t, spec, "Shelter", "resident").Value
+	if want := []string{"#/components/schemas/Cat", "#/components/schemas/Dog"}; !reflect.DeepEqual(refs(resident.OneOf), want) {
+		t.Errorf("resident oneOf = %v, want %v", refs(resident.OneOf), want)
+	}
+	wantDiscriminator := &openapi3.Discriminator{
+		PropertyName: "kind",
+		Mapping:      map[string]string{"cat": "#/components/schemas/Cat", "dog": "#/components/schemas/Dog"},
+	}
+	if !reflect.DeepEqual(resident.Discriminator, wantDiscriminator) {
+		t.Errorf("resident discriminator = %+v, want %+v", resident.Discriminator, wantDiscriminator)
+	}
+	if resident.Description != "Resident of the shelter" {
+		t.Errorf("resident description = %s", resident.Description)
+	}
+
+	visitor := getTestProperty(t, spec, "Shelter", "visitor").Value
+	if want := []string{"#/components/schemas/Cat", "#/components/schemas/Bird"}; !reflect.DeepEqual(refs(visitor.AnyOf), want) {
+		t.Errorf("visitor anyOf = %v, want %v", refs(visitor.AnyOf), want)
+	}
+	if visitor.Discriminator == nil || visitor.Discriminator.PropertyName != "kind" || visitor.Discriminator.Mapping != nil {
+		t.Errorf("visitor discriminator = %+v, want kind without mapping", visitor.Discriminator)
+	}
+
+	mixed := getTestProperty(t, spec, "Shelter", "mixed").Value
+	if want := []string{"#/components/schemas/Animal", "#/components/schemas/Cat"}; !reflect.DeepEqual(refs(mixed.AllOf), want) {
+		t.Errorf("mixed allOf = %v, want %v", refs(mixed.AllOf), want)
+	}
+
+	animal := spec.Components.Schemas["Animal"].Value
+	if !reflect.DeepEqual(animal.Discriminator, wantDiscriminator) {
+		t.Errorf("Animal discriminator = %+v, want %+v", animal.Discriminator, wantDiscriminator)
+	}
+
+	for name, want := range map[string]bool{"Cat": true, "Dog": true, "Bird": false} {
+		schemaRef, ok := spec.Components.Schemas[name]
+		if !ok {
+			t.Fatalf("schema %s not found", name)
+		}
+		if got := hasProperty(schemaRef.Value, "kind", map[*openapi3.Schema]bool{}); got != want {
+			t.Errorf("hasProperty(%s, kind) = %v, want %v", name, got, want)
+		}
+	}
+}
+
+func TestParseDiscriminator(t *testing.T) {
+	tests := []struct {
+		name    string
+		text    string
+		want    *openapi3.Discriminator
+		wantErr bool
+	}{
+		{
+			name: "property",
+			text: "kind",
+			want: &openapi3.Discriminator{PropertyName: "kind"},
+		},
+		{
+			name: "mapping",
+			text: "kind cat=Cat",
+			want: &openapi3.Discriminator{PropertyName: "kind", Mapping: map[string]string{"cat": "#/components/schemas/Cat"}},
+		},
+		{
+			name:    "missing property",
+			text:    "",
+			wantErr: true,
+		},
+		{
+			name:    "invalid mapping",
+			text:    "kind Cat",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseDiscriminator(tt.text)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseDiscriminator() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseDiscriminator() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParser_parseInterfaceType(t *testing.T) {
+	spec := getTestSpec(t, "testdata/models")
+
+	shape := getTestProperty(t, spec, "Drawing", "shape").Value
+	var refs []string
+	for _, ref := range shape.OneOf {
+		refs = append(refs, ref.Ref)
+	}
+	if want := []string{"#/components/schemas/Circle", "#/components/schemas/Square"}; !reflect.DeepEqual(refs, want) {
+		t.Errorf("shape oneOf = %v, want %v", refs, want)
+	}
+	wantDiscriminator := &openapi3.Discriminator{
+		PropertyName: "type",
+		Mapping:      map[string]string{"circle": "#/components/schemas/Circle", "square": "#/components/schemas/Square"},
+	}
+	if !reflect.DeepEqual(shape.Discriminator, wantDiscriminator) {
+		t.Errorf("shape discriminator = %+v, want %+v", shape.Discriminator, wantDiscriminator)
+	}
+	if shape.Description != "Shape of the drawing" {
+		t.Errorf("shape description = %s", shape.Description)
+	}
+
+	notification := getTestProperty(t, spec, "Drawing", "notification").Value
+	if len(notification.OneOf) != 1 || notification.OneOf[0].Ref != "#/components/schemas/Email" {
+		t.Errorf("notification oneOf = %v, want Email", notification.OneOf)
+	}
+	if _, ok := spec.Components.Schemas["Email"]; !ok {
+		t.Errorf("schema Email not found")
+	}
+}
+
+func TestParser_getWireSchema(t *testing.T) {
+	spec := getTestSpec(t, "testdata/models")
+
+	tests := []struct {
+		name       string
+		property   string
+		wantRef    string
+		wantType   string
+		wantFormat string
+	}{
+		{
+			name:     "text marshaler",
+			property: "version",
+			wantType: "string",
+		},
+		{
+			name:       "annotated component",
+			property:   "price",
+			wantRef:    "#/components/schemas/Amount",
+			wantType:   "number",
+			wantFormat: "double",
+		},
+		{
+			name:     "custom json encoding",
+			property: "payload",
+		},
+		{
+			name:       "field override",
+			property:   "build",
+			wantType:   "string",
+			wantFormat: "int64",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := getTestRef(getTestProperty(t, spec, "Release", tt.property))
+			if got.Ref != tt.wantRef {
+				t.Errorf("ref = %s, want %s", got.Ref, tt.wantRef)
+			}
+			if got.Value.Type != tt.wantType || got.Value.Format != tt.wantFormat {
+				t.Errorf("type = %s %s, want %s %s", got.Value.Type, got.Value.Format, tt.wantType, tt.wantFormat)
+			}
+			if len(got.Value.Properties) > 0 {
+				t.Errorf("properties = %v, want none", sortedKeys(got.Value.Properties))
+			}
+		})
+	}
+}
+
+func TestParser_ParseTypeExpr_Numbers(t *testing.T) {
+	spec := getTestSpec(t, "testdata/models")
+
+	floatPtr := func(v float64) *float64 { return &v }
+
+	tests := []struct {
+		property   string
+		wantType   string
+		wantFormat string
+		wantMin    *float64
+		wantMax    *float64
+	}{
+		{property: "id", wantType: "integer", wantFormat: "int64"},
+		{property: "level", wantType: "integer", wantFormat: "int32", wantMin: floatPtr(-128), wantMax: floatPtr(127)},
+		{property: "offset", wantType: "integer", wantFormat: "int32", wantMin: floatPtr(-32768), wantMax: floatPtr(32767)},
+		{property: "channel", wantType: "integer", wantFormat: "int32", wantMin: floatPtr(0), wantMax: floatPtr(255)},
+		{property: "sequence", wantType: "integer", wantFormat: "int64", wantMin: floatPtr(0), wantMax: floatPtr(4294967295)},
+		{property: "total", wantType: "integer", wantFormat: "int64", wantMin: floatPtr(0)},
+		{property: "ratio", wantType: "number", wantFormat: "float"},
+		{property: "value", wantType: "number", wantFormat: "double"},
+		{property: "char", wantType: "integer", wantFormat: "int32"},
+		{property: "counter", wantType: "integer", wantFormat: "int32", wantMin: floatPtr(0), wantMax: floatPtr(65535)},
+		{property: "small", wantType: "integer", wantFormat: "int32"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.property, func(t *testing.T) {
+			got := getTestProperty(t, spec, "Measurement", tt.property).Value
+			if got.Type != tt.wantType || got.Format != tt.wantFormat {
+				t.Errorf("type = %s %s, want %s %s", got.Type, got.Format, tt.wantType, tt.wantFormat)
+			}
+			if !reflect.DeepEqual(got.Min, tt.wantMin) || !reflect.DeepEqual(got.Max, tt.wantMax) {
+				t.Errorf("bounds = %v..%v, want %v..%v", got.Min, got.Max, tt.wantMin, tt.wantMax)
+			}
+		})
+	}
+}
+
+func TestGetParameterSchema(t *testing.T) {
+	tests := []struct {
+		paramType  string
+		wantType   string
+		wantFormat string
+	}{
+		{paramType: "int", wantType: "integer", wantFormat: "int64"},
+		{paramType: "uint8", wantType: "integer", wantFormat: "int32"},
+		{paramType: "float32", wantType: "number", wantFormat: "float"},
+		{paramType: "string", wantType: "string"},
+		{paramType: "integer", wantType: "integer"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.paramType, func(t *testing.T) {
+			got := getParameterSchema(tt.paramType)
+			if got.Type != tt.wantType || got.Format != tt.wantFormat {
+				t.Errorf("getParameterSchema() = %s %s, want %s %s", got.Type, got.Format, tt.wantType, tt.wantFormat)
+			}
+		})
+	}
+}
+
+func TestParser_isNullable(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy NullablePolicy
+		want   []string
+	}{
+		{
+			name:   "explicit",
+			policy: NullablePolicyExplicit,
+			want:   []string{"nickname"},
+		},
+		{
+			name:   "pointer",
+			policy: NullablePolicyPointer,
+			want:   []string{"friend", "name", "nickname"},
+		},
+		{
+			name:   "nil",
+			policy: NullablePolicyNil,
+			want:   []string{"friend", "name", "nickname", "tags"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spec := getTestSpec(t, "testdata/models")
+			if tt.policy != NullablePolicyExplicit {
+				var err error
+				spec, err = NewParser(NewLogger(LogLevelError)).WithNullablePolicy(tt.policy).GetSpec([]string{"testdata/models"})
+				if err != nil {
+					t.Fatalf("GetSpec() error = %v", err)
+				}
+			}
+			patch := spec.Components.Schemas["PetPatch"].Value
+
+			var got []string
+			for _, name := range sortedKeys(patch.Properties) {
+				if patch.Properties[name].Value.Nullable {
+					got = append(got, name)
+				}
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("nullable = %v, want %v", got, tt.want)
+			}
+			if friend := patch.Properties["friend"]; friend.Value.Nullable &&
+				(len(friend.Value.AllOf) != 1 || friend.Value.AllOf[0].Ref != "#/components/schemas/Cat") {
+				t.Errorf("nullable reference = %+v, want allOf Cat", friend.Value)
+			}
+			if spec.Components.Schemas["Cat"].Value.Nullable {
+				t.Errorf("nullable field must not modify the referenced component")
+			}
+		})
+	}
+}
+
+func TestToDocument(t *testing.T) {
+	newSpec := func(version string) *openapi3.T {
+		return &openapi3.T{
+			OpenAPI: version,
+			Components: &openapi3.Components{Schemas: openapi3.Schemas{
+				"Patch": openapi3.NewSchemaRef("", &openapi3.Schema{
+					Type: "object",
+					Properties: openapi3.Schemas{
+						"name":    openapi3.NewSchemaRef("", &openapi3.Schema{Type: "string", Nullable: true}),
+						"kind":    openapi3.NewSchemaRef("", &openapi3.Schema{Type: "string", Enum: []interface{}{"a"}, Nullable: true}),
+						"friend":  openapi3.NewSchemaRef("", &openapi3.Schema{AllOf: openapi3.SchemaRefs{openapi3.NewSchemaRef("#/components/schemas/Cat", nil)}, Nullable: true}),
+						"example": openapi3.NewSchemaRef("", &openapi3.Schema{Type: "integer"}),
+						"pet": openapi3.NewSchemaRef("", &openapi3.Schema{OneOf: openapi3.SchemaRefs{
+							openapi3.NewSchemaRef("#/components/schemas/Cat", nil),
+							openapi3.NewSchemaRef("#/components/schemas/Dog", nil),
+						}, Nullable: true}),
+						"shape": openapi3.NewSchemaRef("", &openapi3.Schema{AnyOf: openapi3.SchemaRefs{
+							openapi3.NewSchemaRef("#/components/schemas/Circle", nil),
+						}, Nullable: true}),
+						"owner": openapi3.NewSchemaRef("", &openapi3.Schema{AllOf: openapi3.SchemaRefs{
+							openapi3.NewSchemaRef("#/components/schemas/Person", nil),
+							openapi3.NewSchemaRef("#/components/schemas/Named", nil),
+						}, Description: "Owner", Nullable: true}),
+						"any": openapi3.NewSchemaRef("", &openapi3.Schema{Description: "Any value", Nullable: true}),
+					},
+				}),
+			}},
+		}
+	}
+	property := func(doc map[string]interface{}, name string) map[string]interface{} {
+		schemas := doc["components"].(map[string]interface{})["schemas"].(map[string]interface{})
+		return schemas["Patch"].(map[string]interface{})["properties"].(map[string]interface{})[name].(map[string]interface{})
+	}
+
+	doc, err := ToDocument(newSpec("3.1.0"))
+	if err != nil {
+		t.Fatalf("ToDocument() error = %v", err)
+	}
+	if got, want := property(doc, "name"), map[string]interface{}{"type": []interface{}{"string", "null"}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("name = %v, want %v", got, want)
+	}
+	if got := property(doc, "kind")["enum"]; !reflect.DeepEqual(got, []interface{}{"a", nil}) {
+		t.Errorf("kind enum = %v, want [a <nil>]", got)
+	}
+	wantFriend := map[string]interface{}{"anyOf": []interface{}{
+		map[string]interface{}{"$ref": "#/components/schemas/Cat"},
+		map[string]interface{}{"type": "null"},
+	}}
+	if got := property(doc, "friend"); !reflect.DeepEqual(got, wantFriend) {
+		t.Errorf("friend = %v, want %v", got, wantFriend)
+	}
+	if got := property(doc, "example")["type"]; got != "integer" {
+		t.Errorf("example type = %v, want integer", got)
+	}
+	wantPet := map[string]interface{}{"oneOf": []interface{}{
+		map[string]interface{}{"$ref": "#/components/schemas/Cat"},
+		map[string]interface{}{"$ref": "#/components/schemas/Dog"},
+		map[string]interface{}{"type": "null"},
+	}}
+	if got := property(doc, "pet"); !reflect.DeepEqual(got, wantPet) {
+		t.Errorf("pet = %v, want %v", got, wantPet)
+	}
+	wantShape := map[string]interface{}{"anyOf": []interface{}{
+		map[string]interface{}{"$ref": "#/components/schemas/Circle"},
+		map[string]interface{}{"type": "null"},
+	}}
+	if got := property(doc, "shape"); !reflect.DeepEqual(got, wantShape) {
+		t.Errorf("shape = %v, want %v", got, wantShape)
+	}
+	wantOwner := map[string]interface{}{
+		"description": "Owner",
+		"anyOf": []interface{}{
+			map[string]interface{}{"allOf": []interface{}{
+				map[string]interface{}{"$ref": "#/components/schemas/Person"},
+				map[string]interface{}{"$ref": "#/components/schemas/Named"},
+			}},
+			map[string]interface{}{"type": "null"},
+		},
+	}
+	if got := property(doc, "owner"); !reflect.DeepEqual(got, wantOwner) {
+		t.Errorf("owner = %v, want %v", got, wantOwner)
+	}
+	if got, want := property(doc, "any"), map[string]interface{}{"description": "Any value"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("any = %v, want %v", got, want)
+	}
+
+	// Exclusive bounds are numbers in JSON Schema 2020-12
+	models, err := ToDocument(getTestSpec(t, "testdata/models"))
+	if err != nil {
+		t.Fatalf("ToDocument() error = %v", err)
+	}
+	schemas := models["components"].(map[string]interface{})["schemas"].(map[string]interface{})
+	price := schemas["Product"].(map[string]interface{})["properties"].(map[string]interface{})["price"].(map[string]interface{})
+	wantPrice := map[string]interface{}{"type": "number", "format": "double", "exclusiveMinimum": 0.0, "maximum": 9999.99}
+	if !reflect.DeepEqual(price, wantPrice) {
+		t.Errorf("price = %v, want %v", price, wantPrice)
+	}
+	age := schemas["Signup"].(map[string]interface{})["properties"].(map[string]interface{})["age"].(map[string]interface{})
+	wantAge := map[string]interface{}{"type": "integer", "format": "int64", "description": "Age in years", "exclusiveMinimum": 0.0, "maximum": 130.0}
+	if !reflect.DeepEqual(age, wantAge) {
+		t.Errorf("age = %v, want %v", age, wantAge)
+	}
+
+	doc, err = ToDocument(newSpec("3.0.3"))
+	if err != nil {
+		t.Fatalf("ToDocument() error = %v", err)
+	}
+	if got, want := property(doc, "name"), map[string]interface{}{"type": "string", "nullable": true}; !reflect.DeepEqual(got, want) {
+		t.Errorf("name = %v, want %v", got, want)
+	}
+}
+
+func TestToYAML(t *testing.T) {
+	data, err := ToYAML(getTestSpec(t, "testdata/models"))
+	if err != nil {
+		t.Fatalf("ToYAML() error = %v", err)
+	}
+	var doc yaml.Node
+	if err = yaml.Unmarshal(data, &doc); err != nil {
+		t.Fatalf("yaml.Unmarshal() error = %v", err)
+	}
+	keys := func(node *yaml.Node, path ...string) []string {
+		for _, name := range path {
+			var child *yaml.Node
+			for i := 0; i+1 < len(node.Content); i += 2 {
+				if node.Content[i].Value == name {
+					child = node.Content[i+1]
+				}
+			}
+			if child == nil {
+				t.Fatalf("key %s not found", name)
+			}
+			node = child
+		}
+		var keys []string
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			keys = append(keys, node.Content[i].Value)
+		}
+		return keys
+	}
+
+	// Keys follow the fields of the openapi3 types, extensions come last
+	root := doc.Content[0]
+	if got, want := keys(root), []string{"openapi", "components", "info", "paths", "servers"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("keys = %v, want %v", got, want)
+	}
+	want := []string{"type", "enum", "x-enum-descriptions", "x-enum-varnames"}
+	if got := keys(root, "components", "schemas", "Color"); !reflect.DeepEqual(got, want) {
+		t.Errorf("Color keys = %v, want %v", got, want)
+	}
+	want = []string{"type", "format", "description", "exclusiveMinimum", "maximum"}
+	if got := keys(root, "components", "schemas", "Signup", "properties", "age"); !reflect.DeepEqual(got, want) {
+		t.Errorf("Signup age keys = %v, want %v", got, want)
+	}
+}
+
+func TestParser_createCollectionSchema(t *testing.T) {
+	spec := getTestSpec(t, "testdata/models")
+
+	tests := []struct {
+		name        string
+		schema      string
+		wantType    string
+		wantItems   string
+		wantValues  string
+		description string
+	}{
+		{
+			name:        "slice",
+			schema:      "Pets",
+			wantType:    "array",
+			wantItems:   "#/components/schemas/Pet",
+			description: "Pets of a kennel",
+		},
+		{
+			name:        "map",
+			schema:      "Labels",
+			wantType:    "object",
+			wantValues:  "string",
+			description: "Labels attached to a kennel",
+		},
+		{
+			name:      "recursive",
+			schema:    "Forest",
+			wantType:  "array",
+			wantItems: "#/components/schemas/Grove",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schemaRef, ok := spec.Components.Schemas[tt.schema]
+			if !ok {
+				t.Fatalf("schema %s not found", tt.schema)
+			}
+			schema := schemaRef.Value
+			if schema.Type != tt.wantType {
+				t.Errorf("type = %s, want %s", schema.Type, tt.wantType)
+			}
+			if schema.Description != tt.description {
+				t.Errorf("description = %q, want %q", schema.Description, tt.description)
+			}
+			if len(tt.wantItems) > 0 && (schema.Items == nil || schema.Items.Ref != tt.wantItems) {
+				t.Errorf("items = %+v, want %s", schema.Items, tt.wantItems)
+			}
+			if len(tt.wantValues) > 0 {
+				values := schema.AdditionalProperties.Schema
+				if values == nil || values.Value.Type != tt.wantValues {
+					t.Errorf("additionalProperties = %+v, want %s", values, tt.wantValues)
+				}
+			}
+		})
+	}
+
+	refs := map[string]string{
+		"pets":   "#/components/schemas/Pets",
+		"labels": "#/components/schemas/Labels",
+	}
+	for property, want := range refs {
+		if got := getTestRef(getTestProperty(t, spec, "Kennel", property)).Ref; got != want {
+			t.Errorf("property %s ref = %s, want %s", property, got, want)
+		}
+	}
+	if runs := getTestProperty(t, spec, "Kennel", "runs"); runs.Value.Items == nil || runs.Value.Items.Ref != "#/components/schemas/Pets" {
+		t.Errorf("items of runs = %+v, want #/components/schemas/Pets", runs.Value.Items)
+	}
+	if groves := getTestRef(getTestProperty(t, spec, "Grove", "groves")); groves.Ref != "#/components/schemas/Forest" {
+		t.Errorf("property groves ref = %s, want #/components/schemas/Forest", groves.Ref)
+	}
+
+	// Collections without openapi:schema are inlined
+	annotations := getTestProperty(t, spec, "Kennel", "annotations")
+	if len(annotations.Ref) > 0 || annotations.Value.Type != "object" {
+		t.Errorf("property annotations = %+v, want inlined object", annotations)
+	}
+	if _, ok := spec.Components.Schemas["Annotations"]; ok {
+		t.Errorf("schema Annotations must not be a component")
+	}
+}
+
+func TestParser_applyFragment(t *testing.T) {
+	spec := getTestSpec(t, "testdata/models")
+
+	webhook := spec.Components.Schemas["Webhook"].Value
+	if webhook.ExternalDocs == nil || webhook.ExternalDocs.URL != "https://example.com/webhooks" {
+		t.Errorf("externalDocs = %+v, want https://example.com/webhooks", webhook.ExternalDocs)
+	}
+	if webhook.Extensions["x-internal"] != true {
+		t.Errorf("x-internal = %v, want true", webhook.Extensions["x-internal"])
+	}
+
+	url := getTestProperty(t, spec, "Webhook", "url").Value
+	if url.Format != "uri" || url.MaxLength == nil || *url.MaxLength != 2048 || url.Description != "Target of the webhook" {
+		t.Errorf("property url = %+v, want uri of at most 2048 characters", url)
+	}
+
+	// Fragments of references must not modify the component
+	pet := getTestProperty(t, spec, "Webhook", "pet")
+	if len(pet.Value.AllOf) != 1 || pet.Value.AllOf[0].Ref != "#/components/schemas/Pet" || pet.Value.Extensions["x-sample"] != true {
+		t.Errorf("property pet = %+v, want allOf Pet with x-sample", pet.Value)
+	}
+	if _, ok := spec.Components.Schemas["Pet"].Value.Extensions["x-sample"]; ok {
+		t.Errorf("schema Pet must not have x-sample")
+	}
+
+	op := spec.Paths.Find("/webhooks").Post
+	if op == nil {
+		t.Fatalf("operation createWebhook not found")
+	}
+	if op.Callbacks["delivery"] == nil || op.Extensions["x-rate-limit"] != 10 {
+		t.Errorf("operation = %+v, want callbacks and x-rate-limit", op)
+	}
+	created := op.Responses["201"].Value
+	if created.Headers["Location"] == nil || created.Content.Get("*/*") == nil || *created.Description != "Created webhook" {
+		t.Errorf("response 201 = %+v, want the generated response with a Location header", created)
+	}
+}
@@ -0,0 +1,255 @@
+package scan
+
+import (
+	"fmt"
+	"go/token"
+	"sort"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// securityCheck are security requirements whose schemes are validated once all schemes are declared.
+type securityCheck struct {
+	// key is the operation id, or openapi:meta security for the default requirements
+	key          string
+	pos          token.Pos
+	requirements openapi3.SecurityRequirements
+}
+
+// securitySchemeFormats are the formats of the openapi:meta securityScheme annotation by scheme type.
+var securitySchemeFormats = map[string]string{
+	"http":          "openapi:meta securityScheme <Name> http <Scheme> [bearerFormat=<Format>] [--- Description]",
+	"apiKey":        "openapi:meta securityScheme <Name> apiKey <header|query|cookie> <Parameter> [--- Description]",
+	"oauth2":        "openapi:meta securityScheme <Name> oauth2 <Flow> [authorizationUrl=<URL>] [tokenUrl=<URL>] [refreshUrl=<URL>] [--- Description]",
+	"openIdConnect": "openapi:meta securityScheme <Name> openIdConnect <URL> [--- Description]",
+}
+
+// addSecurityScheme adds the security scheme of the annotation
+// `openapi:meta securityScheme <Name> <Type> ...` to the components. The flows of oauth2 schemes are
+// declared with one annotation each.
+func (p *Parser) addSecurityScheme(d *directive) {
+	name, schemeType := d.arg(1), d.arg(2)
+	format, ok := securitySchemeFormats[schemeType]
+	if len(name) == 0 || !ok {
+		p.report(&Diagnostic{
+			Severity:   SeverityError,
+			Directive:  "openapi:meta securityScheme",
+			Message:    fmt.Sprintf("unsupported security scheme type %q of %s", schemeType, name),
+			Suggestion: "expected http, apiKey, oauth2 or openIdConnect",
+		}, d.Pos)
+		return
+	}
+	invalid := func(message string) {
+		p.report(&Diagnostic{
+			Severity:   SeverityError,
+			Directive:  "openapi:meta securityScheme",
+			Message:    fmt.Sprintf("%s of %s, the scheme is skipped", message, name),
+			Suggestion: "expected " + format,
+		}, d.Pos)
+	}
+
+	if p.spec.Components.SecuritySchemes == nil {
+		p.spec.Components.SecuritySchemes = openapi3.SecuritySchemes{}
+	}
+	scheme := &openapi3.SecurityScheme{Type: schemeType, Description: d.Description}
+	if existing, ok := p.spec.Components.SecuritySchemes[name]; ok {
+		if schemeType != "oauth2" || existing.Value.Type != "oauth2" {
+			invalid("duplicate declaration")
+			return
+		}
+		// Further flows of an oauth2 scheme
+		scheme = existing.Value
+		if len(d.Description) > 0 {
+			scheme.Description = d.Description
+		}
+	}
+
+	switch schemeType {
+	case "http":
+		if len(d.Args) != 4 {
+			invalid("invalid format")
+			return
+		}
+		scheme.Scheme = d.Args[3]
+		scheme.BearerFormat = d.Options["bearerFormat"]
+	case "apiKey":
+		if len(d.Args) != 5 {
+			invalid("invalid format")
+			return
+		}
+		switch d.Args[3] {
+		case "header", "query", "cookie":
+		default:
+			invalid(fmt.Sprintf("unsupported location %s", d.Args[3]))
+			return
+		}
+		scheme.In, scheme.Name = d.Args[3], d.Args[4]
+	case "oauth2":
+		if len(d.Args) != 4 {
+			invalid("invalid format")
+			return
+		}
+		flow := &openapi3.OAuthFlow{
+			AuthorizationURL: d.Options["authorizationUrl"],
+			TokenURL:         d.Options["tokenUrl"],
+			RefreshURL:       d.Options["refreshUrl"],
+			Scopes:           map[string]string{},
+		}
+		if scheme.Flows == nil {
+			scheme.Flows = &openapi3.OAuthFlows{}
+		}
+		var target **openapi3.OAuthFlow
+		var needsAuthorization, needsToken bool
+		switch d.Args[3] {
+		case "implicit":
+			target, needsAuthorization = &scheme.Flows.Implicit, true
+		case "password":
+			target, needsToken = &scheme.Flows.Password, true
+		case "clientCredentials":
+			target, needsToken = &scheme.Flows.ClientCredentials, true
+		case "authorizationCode":
+			target, needsAuthorization, needsToken = &scheme.Flows.AuthorizationCode, true, true
+		default:
+			invalid(fmt.Sprintf("unsupported flow %s", d.Args[3]))
+			return
+		}
+		if needsAuthorization && len(flow.AuthorizationURL) == 0 || needsToken && len(flow.TokenURL) == 0 {
+			invalid(fmt.Sprintf("missing URL of the %s flow", d.Args[3]))
+			return
+		}
+		*target = flow
+	case "openIdConnect":
+		if len(d.Args) != 4 {
+			invalid("invalid format")
+			return
+		}
+		scheme.OpenIdConnectUrl = d.Args[3]
+	}
+
+	p.spec.Components.SecuritySchemes[name] = &openapi3.SecuritySchemeRef{Value: scheme}
+}
+
+// addSecurityScope adds the scope of the annotation `openapi:meta securityScope <Name> <Scope> --- <Description>`
+// to every flow of the oauth2 scheme declared before.
+func (p *Parser) addSecurityScope(d *directive) {
+	if len(d.Args) != 3 {
+		p.report(&Diagnostic{
+			Severity:   SeverityError,
+			Directive:  "openapi:meta securityScope",
+			Message:    "invalid format",
+			Suggestion: "expected openapi:meta securityScope <Name> <Scope> --- <Description>",
+		}, d.Pos)
+		return
+	}
+	name, scope := d.Args[1], d.Args[2]
+	schemeRef, ok := p.spec.Components.SecuritySchemes[name]
+	if !ok || schemeRef.Value.Flows == nil {
+		p.report(&Diagnostic{
+			Severity:   SeverityError,
+			Directive:  "openapi:meta securityScope",
+			Message:    fmt.Sprintf("scope %s of unknown oauth2 scheme %s", scope, name),
+			Suggestion: "declare the scheme with openapi:meta securityScheme before its scopes",
+		}, d.Pos)
+		return
+	}
+	for _, flow := range oauthFlows(schemeRef.Value.Flows) {
+		flow.Scopes[scope] = d.Description
+	}
+}
+
+// addDefaultSecurity adds the requirement of the annotation `openapi:meta security <Name> [Scope] ...` to the
+// security of all operations that do not declare their own.
+func (p *Parser) addDefaultSecurity(d *directive) {
+	if len(d.Args) < 2 {
+		p.report(&Diagnostic{
+			Severity:   SeverityError,
+			Directive:  "openapi:meta security",
+			Message:    "invalid format",
+			Suggestion: "expected openapi:meta security <Name> [Scope] ...",
+		}, d.Pos)
+		return
+	}
+	requirement := openapi3.SecurityRequirement{d.Args[1]: append([]string{}, d.Args[2:]...)}
+	p.spec.Security = append(p.spec.Security, requirement)
+	p.security = append(p.security, &securityCheck{
+		key:          "openapi:meta security",
+		pos:          d.Pos,
+		requirements: openapi3.SecurityRequirements{requirement},
+	})
+}
+
+// validateSecurity reports security requirements naming undeclared schemes or scopes.
+func (p *Parser) validateSecurity() {
+	schemes := p.spec.Components.SecuritySchemes
+	for _, check := range p.security {
+		for _, requirement := range check.requirements {
+			names := make([]string, 0, len(requirement))
+			for name := range requirement {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+
+			for _, name := range names {
+				schemeRef, ok := schemes[name]
+				if !ok {
+					diagnostic := &Diagnostic{
+						Severity:   SeverityError,
+						Directive:  "openapi:security",
+						Message:    fmt.Sprintf("unknown security scheme %s of %s", name, check.key),
+						Suggestion: "declare it with openapi:meta securityScheme",
+					}
+					if suggestion := suggestScheme(name, schemes); len(suggestion) > 0 {
+						diagnostic.Suggestion = fmt.Sprintf("did you mean %s?", suggestion)
+					}
+					p.report(diagnostic, check.pos)
+					continue
+				}
+				if schemeRef.Value.Type != "oauth2" {
+					continue
+				}
+				for _, scope := range requirement[name] {
+					if !hasScope(schemeRef.Value.Flows, scope) {
+						p.warn(check.pos, "openapi:security", "scope %s of %s is not declared by scheme %s", scope, check.key, name)
+					}
+				}
+			}
+		}
+	}
+}
+
+// suggestScheme returns the declared scheme nearest to name, or an empty string if none is similar.
+func suggestScheme(name string, schemes openapi3.SecuritySchemes) string {
+	var suggestion string
+	best := len(name)/3 + 2
+	for known := range schemes {
+		distance := editDistance(name, known)
+		if distance < best || distance == best && known < suggestion {
+			suggestion, best = known, distance
+		}
+	}
+	return suggestion
+}
+
+// oauthFlows returns the declared flows.
+func oauthFlows(flows *openapi3.OAuthFlows) []*openapi3.OAuthFlow {
+	var declared []*openapi3.OAuthFlow
+	for _, flow := range []*openapi3.OAuthFlow{flows.Implicit, flows.Password, flows.ClientCredentials, flows.AuthorizationCode} {
+		if flow != nil {
+			declared = append(declared, flow)
+		}
+	}
+	return declared
+}
+
+// hasScope reports whether any of the flows declares scope.
+func hasScope(flows *openapi3.OAuthFlows, scope string) bool {
+	if flows == nil {
+		return false
+	}
+	for _, flow := range oauthFlows(flows) {
+		if _, ok := flow.Scopes[scope]; ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,138 @@
+package scan
+
+import (
+	"go/ast"
+	"reflect"
+	"testing"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+func TestParser_addSecurityScheme(t *testing.T) {
+	spec := getTestSpec(t, "testdata/handlers")
+
+	schemes := spec.Components.SecuritySchemes
+	tests := []struct {
+		name string
+		want *openapi3.SecurityScheme
+	}{
+		{
+			name: "bearer",
+			want: &openapi3.SecurityScheme{Type: "http", Scheme: "bearer", BearerFormat: "JWT", Description: "Access token of the user"},
+		},
+		{
+			name: "basic",
+			want: &openapi3.SecurityScheme{Type: "http", Scheme: "basic"},
+		},
+		{
+			name: "apiKey",
+			want: &openapi3.SecurityScheme{Type: "apiKey", In: "header", Name: "X-API-Key", Description: "Key of the client"},
+		},
+		{
+			name: "oidc",
+			want: &openapi3.SecurityScheme{Type: "openIdConnect", OpenIdConnectUrl: "https://auth.example.com/.well-known/openid-configuration"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schemeRef, ok := schemes[tt.name]
+			if !ok {
+				t.Fatalf("security scheme %s not found", tt.name)
+			}
+			if !reflect.DeepEqual(schemeRef.Value, tt.want) {
+				t.Errorf("security scheme = %+v, want %+v", schemeRef.Value, tt.want)
+			}
+		})
+	}
+
+	oauth := schemes["oauth"].Value
+	scopes := map[string]string{"owners:read": "Read the owners", "owners:write": "Modify the owners"}
+	if oauth.Flows == nil || oauth.Flows.AuthorizationCode == nil || oauth.Flows.ClientCredentials == nil {
+		t.Fatalf("flows = %+v, want authorizationCode and clientCredentials", oauth.Flows)
+	}
+	code := oauth.Flows.AuthorizationCode
+	if code.AuthorizationURL != "https://auth.example.com/authorize" || code.TokenURL != "https://auth.example.com/token" ||
+		!reflect.DeepEqual(code.Scopes, scopes) {
+		t.Errorf("authorizationCode flow = %+v", code)
+	}
+	if !reflect.DeepEqual(oauth.Flows.ClientCredentials.Scopes, scopes) {
+		t.Errorf("clientCredentials scopes = %v, want %v", oauth.Flows.ClientCredentials.Scopes, scopes)
+	}
+
+	want := openapi3.SecurityRequirements{{"apiKey": {}}, {"oauth": {"owners:read"}}}
+	if !reflect.DeepEqual(spec.Security, want) {
+		t.Errorf("security = %v, want %v", spec.Security, want)
+	}
+}
+
+func TestParser_validateSecurity(t *testing.T) {
+	tests := []struct {
+		name     string
+		comments []string
+		want     []string
+	}{
+		{
+			name: "valid",
+			comments: []string{
+				"// openapi:meta securityScheme oauth oauth2 clientCredentials tokenUrl=https://example.com/token",
+				"// openapi:meta securityScope oauth read --- Read",
+				"// openapi:meta security oauth read",
+			},
+		},
+		{
+			name: "unknown scheme",
+			comments: []string{
+				"// openapi:meta securityScheme bearer http bearer",
+				"// openapi:meta security beerer",
+			},
+			want: []string{"error: openapi:security: unknown security scheme beerer of openapi:meta security (did you mean bearer?)"},
+		},
+		{
+			name: "unknown scope",
+			comments: []string{
+				"// openapi:meta securityScheme oauth oauth2 clientCredentials tokenUrl=https://example.com/token",
+				"// openapi:meta security oauth write",
+			},
+			want: []string{"warning: openapi:security: scope write of openapi:meta security is not declared by scheme oauth"},
+		},
+		{
+			name: "malformed schemes",
+			comments: []string{
+				"// openapi:meta securityScheme key apiKey body X-Key",
+				"// openapi:meta securityScheme oauth oauth2 implicit tokenUrl=https://example.com/token",
+				"// openapi:meta securityScheme mtls mutualTLS",
+				"// openapi:meta securityScope other read --- Read",
+			},
+			want: []string{
+				"error: openapi:meta securityScheme: unsupported location body of key, the scheme is skipped " +
+					"(expected openapi:meta securityScheme <Name> apiKey <header|query|cookie> <Parameter> [--- Description])",
+				"error: openapi:meta securityScheme: missing URL of the implicit flow of oauth, the scheme is skipped " +
+					"(expected openapi:meta securityScheme <Name> oauth2 <Flow> [authorizationUrl=<URL>] [tokenUrl=<URL>] [refreshUrl=<URL>] [--- Description])",
+				`error: openapi:meta securityScheme: unsupported security scheme type "mutualTLS" of mtls ` +
+					"(expected http, apiKey, oauth2 or openIdConnect)",
+				"error: openapi:meta securityScope: scope read of unknown oauth2 scheme other " +
+					"(declare the scheme with openapi:meta securityScheme before its scopes)",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewParser(NewLogger(LogLevelFatal))
+			cg := &ast.CommentGroup{}
+			for _, text := range tt.comments {
+				cg.List = append(cg.List, &ast.Comment{Text: text})
+			}
+			p.extractOpenAPIInfo(cg)
+			p.validateSecurity()
+
+			var got []string
+			for _, d := range p.Diagnostics() {
+				got = append(got, d.String())
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Diagnostics() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
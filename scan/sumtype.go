@@ -0,0 +1,164 @@
+package scan
+
+import (
+	"fmt"
+	"go/ast"
+	"go/constant"
+	"go/types"
+	"sort"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// parseInterfaceType returns a oneOf of the structs implementing the interface decl. The discriminator
+// of the interface may name a method returning a constant, e.g. `openapi:discriminator kind Kind()`,
+// to map the returned values to the implementing structs.
+func (p *Parser) parseInterfaceType(key string, obj *types.TypeName, decl *typeDecl) *openapi3.SchemaRef {
+	iface, ok := obj.Type().Underlying().(*types.Interface)
+	if !ok {
+		return nil
+	}
+
+	implementers := p.getImplementers(obj, iface)
+	if len(implementers) == 0 {
+		p.report(&Diagnostic{
+			Severity:   SeverityWarning,
+			Message:    fmt.Sprintf("no implementations of %s found for %s", decl.spec.Name.Name, key),
+			Suggestion: "annotate them with openapi:schema or openapi:implements",
+		}, decl.spec.Pos())
+		return nil
+	}
+
+	schema := &openapi3.Schema{}
+	names := map[*typeDecl]string{}
+	for _, implementer := range implementers {
+		name := p.registerTypeDecl(implementer)
+		names[implementer] = name
+		schema.OneOf = append(schema.OneOf, &openapi3.SchemaRef{
+			Ref:   fmt.Sprintf("#/components/schemas/%s", name),
+			Value: p.createOpenAPISchema(name, implementer.spec),
+		})
+	}
+
+	d := findDirective(decl.doc, "discriminator")
+	if d == nil {
+		return openapi3.NewSchemaRef("", schema)
+	}
+
+	// The method deriving the discriminator values is not part of the discriminator annotation
+	var method string
+	var fields []string
+	for _, arg := range d.Args {
+		if strings.HasSuffix(arg, "()") {
+			method = strings.TrimSuffix(arg, "()")
+		} else {
+			fields = append(fields, quoteArg(arg))
+		}
+	}
+	for value, name := range d.Options {
+		fields = append(fields, quoteArg(value)+"="+quoteArg(name))
+	}
+	for _, implementer := range implementers {
+		if len(method) == 0 {
+			break
+		}
+		value, ok := p.getMethodConstant(implementer, method)
+		if !ok {
+			p.warn(implementer.spec.Pos(), "openapi:discriminator", "method %s of %s does not return a constant",
+				method, implementer.spec.Name.Name)
+			continue
+		}
+		fields = append(fields, quoteArg(value)+"="+names[implementer])
+	}
+
+	p.addDiscriminator(key, decl.spec.Pos(), schema, strings.Join(fields, " "), schema.OneOf)
+	return openapi3.NewSchemaRef("", schema)
+}
+
+// getImplementers returns the structs of the scanned packages implementing the interface obj. Structs
+// annotated with openapi:schema are matched by their method set, any other struct must be annotated
+// with `openapi:implements <Interface>`.
+func (p *Parser) getImplementers(obj *types.TypeName, iface *types.Interface) []*typeDecl {
+	var implementers []*typeDecl
+	seen := map[*typeDecl]bool{}
+	add := func(decl *typeDecl) {
+		if !seen[decl] {
+			seen[decl] = true
+			implementers = append(implementers, decl)
+		}
+	}
+
+	keys := make([]string, 0, len(p.typeDecls))
+	for key := range p.typeDecls {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		decl := p.typeDecls[key]
+		if _, ok := decl.spec.Type.(*ast.StructType); !ok || decl.spec.TypeParams != nil || !p.isScanned(decl.pkg.PkgPath) {
+			continue
+		}
+
+		if d := findDirective(decl.doc, "implements"); d != nil {
+			for _, name := range d.Args {
+				if p.lookupTypeName(decl.pkg, name) == obj {
+					add(decl)
+				}
+			}
+		}
+
+		// Empty interfaces are implemented by every type, only the annotated implementations are used
+		if iface.NumMethods() == 0 || !parseStructComment(decl.spec.Name.Name, decl.doc).Schema {
+			continue
+		}
+		tn, ok := decl.pkg.TypesInfo.Defs[decl.spec.Name].(*types.TypeName)
+		if ok && (types.Implements(tn.Type(), iface) || types.Implements(types.NewPointer(tn.Type()), iface)) {
+			add(decl)
+		}
+	}
+	return implementers
+}
+
+// getMethodConstant returns the constant returned by the method of decl, e.g. `func (Circle) Kind() string { return "circle" }`.
+func (p *Parser) getMethodConstant(decl *typeDecl, method string) (string, bool) {
+	tn, ok := decl.pkg.TypesInfo.Defs[decl.spec.Name].(*types.TypeName)
+	if !ok {
+		return "", false
+	}
+	sel := types.NewMethodSet(types.NewPointer(tn.Type())).Lookup(tn.Pkg(), method)
+	if sel == nil {
+		return "", false
+	}
+	fn, ok := sel.Obj().(*types.Func)
+	if !ok {
+		return "", false
+	}
+
+	pkg := p.packageOf(fn.Pos())
+	if pkg == nil {
+		return "", false
+	}
+	for _, file := range pkg.Syntax {
+		for _, d := range file.Decls {
+			fd, ok := d.(*ast.FuncDecl)
+			if !ok || fd.Name.Pos() != fn.Pos() || fd.Body == nil || len(fd.Body.List) != 1 {
+				continue
+			}
+			ret, ok := fd.Body.List[0].(*ast.ReturnStmt)
+			if !ok || len(ret.Results) != 1 {
+				return "", false
+			}
+			tv, ok := pkg.TypesInfo.Types[ret.Results[0]]
+			if !ok || tv.Value == nil {
+				return "", false
+			}
+			if tv.Value.Kind() == constant.String {
+				return constant.StringVal(tv.Value), true
+			}
+			return tv.Value.ExactString(), true
+		}
+	}
+	return "", false
+}
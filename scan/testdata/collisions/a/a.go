@@ -0,0 +1,12 @@
+package a
+
+// Error is returned by the a service
+type Error struct {
+	// openapi:description Code of the a error
+	Code int `json:"code"`
+}
+
+// Item is listed by the a service
+type Item struct {
+	ID int `json:"id"`
+}
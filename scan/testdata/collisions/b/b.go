@@ -0,0 +1,12 @@
+package b
+
+// Error is returned by the b service
+type Error struct {
+	// openapi:description Message of the b error
+	Message string `json:"message"`
+}
+
+// Item is listed by the b service
+type Item struct {
+	Name string `json:"name"`
+}
@@ -0,0 +1,24 @@
+package collisions
+
+import (
+	"github.com/vasusheoran/go-openapi/scan/testdata/collisions/a"
+	"github.com/vasusheoran/go-openapi/scan/testdata/collisions/b"
+)
+
+// Result ...
+// openapi:schema
+type Result struct {
+	// openapi:description Error of the a service
+	AError a.Error `json:"aError"`
+	// openapi:description Error of the b service
+	BError b.Error `json:"bError"`
+	// openapi:description Items of the a service
+	APage Page[a.Item] `json:"aPage"`
+	// openapi:description Items of the b service
+	BPage Page[b.Item] `json:"bPage"`
+}
+
+// Page is a page of items
+type Page[T any] struct {
+	Items []T `json:"items"`
+}
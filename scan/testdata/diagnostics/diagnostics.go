@@ -0,0 +1,58 @@
+package diagnostics
+
+// Pet ...
+// openapi:schema
+type Pet struct {
+	// openapi:descripton Name of the pet
+	Name string `json:"name"`
+}
+
+// PetsInterface ...
+type PetsInterface interface {
+	// ListPets Lists the pets
+	// openapi:operation GET /pets listPets
+	// openapi:produce application/json
+	// openapi:response 200 Pet --- The pets
+	ListPets() (Pet, error)
+
+	// GetPet Fetches a pet
+	// openapi:operation GET /pets/{id} getPet
+	// openapi:param id path string --- ID of the pet
+	// openapi:response 200 Pet --- The pet
+	GetPet(id string) (Pet, error)
+}
+
+// DeletePet Deletes a pet
+// openapi:operation DELETE "/pets/{id} deletePet
+func DeletePet() {}
+
+// Mood is the mood of a pet, encoded by its name
+type Mood int
+
+const (
+	MoodCalm Mood = iota
+	MoodPlayful
+)
+
+func (m Mood) String() string {
+	if m == MoodCalm {
+		return "calm"
+	}
+	return "playful"
+}
+
+// MarshalText encodes the mood by its name
+func (m Mood) MarshalText() ([]byte, error) {
+	return []byte(m.String()), nil
+}
+
+// Profile ...
+// openapi:schema
+type Profile struct {
+	// openapi:description Mood of the pet
+	Mood Mood `json:"mood"`
+	// openapi:description Scores of the pet by year
+	Scores map[int]int `json:"scores"`
+}
+
+var defaultMood Mood = "calm"
@@ -0,0 +1,14 @@
+// openapi:meta info title Owners API
+// openapi:meta securityScheme bearer http bearer bearerFormat=JWT --- Access token of the user
+// openapi:meta securityScheme basic http basic
+// openapi:meta securityScheme apiKey apiKey header X-API-Key --- Key of the client
+// openapi:meta securityScheme oauth oauth2 authorizationCode authorizationUrl=https://auth.example.com/authorize
+//   tokenUrl=https://auth.example.com/token
+// openapi:meta securityScheme oauth oauth2 clientCredentials tokenUrl=https://auth.example.com/token
+// openapi:meta securityScope oauth owners:read --- Read the owners
+// openapi:meta securityScope oauth owners:write --- Modify the owners
+// openapi:meta securityScheme oidc openIdConnect https://auth.example.com/.well-known/openid-configuration
+// openapi:meta security apiKey
+// openapi:meta security oauth owners:read
+
+package handlers
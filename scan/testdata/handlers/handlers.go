@@ -0,0 +1,72 @@
+package handlers
+
+// Owner ...
+// openapi:schema
+type Owner struct {
+	// openapi:description Name of the owner
+	Name string `json:"name"`
+}
+
+// ErrorResponse ...
+// openapi:schema
+type ErrorResponse struct {
+	// openapi:description Error message
+	Message string `json:"message"`
+}
+
+// OwnersInterface handles the owners, its directives apply to every operation
+// openapi:basePath /v1
+// openapi:tag owners
+// openapi:security bearer
+// openapi:produces application/json
+// openapi:param x-request-id header string false --- ID of the request
+// openapi:response 400 ErrorResponse --- Invalid request
+// openapi:response 500 ErrorResponse --- Internal error
+type OwnersInterface interface {
+	// ListOwners Lists the owners
+	// openapi:operation GET /owners listOwners
+	// openapi:response 200 Owner --- The owners
+	ListOwners() ([]Owner, error)
+
+	// GetOwner Fetches an owner
+	// openapi:operation GET /owners/{id} getOwner
+	// openapi:tag owners
+	// openapi:tag lookup
+	// openapi:security none
+	// openapi:param id path string true --- ID of the owner
+	// openapi:param x-request-id header string true --- Required ID of the request
+	// openapi:response 200 Owner --- The owner
+	// openapi:response 400 ErrorResponse --- Invalid ID
+	GetOwner(id string) (Owner, error)
+}
+
+// OwnerHandler serves the owners of the admin API
+// openapi:basePath /admin/
+// openapi:consumes application/json
+// openapi:security oauth owners:write
+type OwnerHandler struct{}
+
+// CreateOwner Creates an owner
+// openapi:operation POST /owners createOwner
+// openapi:body Owner --- Owner to create
+// openapi:response 201 Owner --- Created owner
+func (h *OwnerHandler) CreateOwner() {}
+
+// Health Reports the health of the service
+// openapi:operation GET /health health
+// openapi:response 204 --- Healthy
+func Health() {}
+
+// AuditHandler serves the audit log
+// openapi:security
+type AuditHandler struct{}
+
+// ListEvents Lists the audit events
+// openapi:operation GET /events listEvents
+// openapi:response 204 --- No events
+func (h AuditHandler) ListEvents() {}
+
+// PurgeEvents Purges the audit events
+// openapi:operation DELETE /events purgeEvents
+// openapi:response 204 --- Purged
+func (h AuditHandler) PurgeEvents() {}
@@ -0,0 +1,694 @@
+package models
+
+import (
+	"encoding/json"
+	"net"
+	"time"
+)
+
+// Pet ...
+// openapi:schema
+type Pet struct {
+	// openapi:description Name of the pet
+	Name string `json:"name"`
+	// openapi:description Status of the pet
+	Status PetStatus `json:"status"`
+	// openapi:description Size of the pet
+	Size Size `json:"size"`
+}
+
+// PetStatus is the adoption status of a pet
+// openapi:schema
+type PetStatus string
+
+const (
+	// PetStatusAvailable The pet is available for adoption
+	PetStatusAvailable PetStatus = "available"
+	// PetStatusSold The pet was adopted
+	PetStatusSold PetStatus = "sold"
+)
+
+// Size is the size of a pet
+type Size int
+
+const (
+	SizeSmall  Size = iota // Fits in a bag
+	SizeMedium             // Fits in a car
+	SizeLarge
+)
+
+func (s Size) String() string {
+	return [...]string{"small", "medium", "large"}[s]
+}
+
+// Config ...
+// openapi:schema
+type Config struct {
+	// openapi:description Labels of the config
+	Labels map[string]string `json:"labels"`
+	// openapi:description Pets by name
+	Pets map[string]Pet `json:"pets"`
+	// openapi:description Counters by ID, keys are serialized as strings
+	Counters map[int]int `json:"counters"`
+	// openapi:description Annotations of the config
+	Annotations Annotations `json:"annotations"`
+}
+
+// Annotations ...
+type Annotations map[string]string
+
+// ConfigInterface ...
+type ConfigInterface interface {
+	// GetAnnotations Fetches the annotations
+	// openapi:operation GET /annotations getAnnotations
+	// openapi:produces application/json
+	// openapi:response 200 Annotations --- Annotations of the config
+	GetAnnotations() (Annotations, error)
+}
+
+// Metadata ...
+type Metadata struct {
+	// openapi:description ID of the resource
+	ID string `json:"id"`
+	// openapi:description Name of the resource
+	Name string `json:"name"`
+}
+
+// BaseModel ...
+// openapi:schema
+type BaseModel struct {
+	// openapi:description Version of the model
+	Version int `json:"version"`
+}
+
+// Resource ...
+// openapi:schema
+type Resource struct {
+	*Metadata
+	// openapi:description Display name of the resource
+	Name string `json:"name"`
+	// openapi:description Owner of the resource
+	Owner string `json:"owner"`
+}
+
+// Versioned ...
+// openapi:schema
+type Versioned struct {
+	// openapi:allOf
+	BaseModel
+	// openapi:description Label of the version
+	Label string `json:"label"`
+}
+
+// Page is a page of items
+// openapi:schema
+type Page[T any] struct {
+	// openapi:description Items of the page
+	Items []T `json:"items"`
+	// openapi:description Token of the next page
+	Next string `json:"next"`
+}
+
+// Envelope ...
+type Envelope[T any, M any] struct {
+	// openapi:description Wrapped data
+	Data T `json:"data"`
+	// openapi:description Metadata of the data
+	Meta M `json:"meta"`
+}
+
+// PetFeed ...
+// openapi:schema
+type PetFeed struct {
+	Page[Pet]
+	// openapi:description Title of the feed
+	Title string `json:"title"`
+}
+
+// PetList ...
+// openapi:schema
+type PetList struct {
+	// openapi:description Pets of the list
+	Pets Page[Pet] `json:"pets"`
+	// openapi:description Pets wrapped in an envelope
+	Wrapped Envelope[Page[*Pet], string] `json:"wrapped"`
+}
+
+// PetsInterface ...
+type PetsInterface interface {
+	// ListPets Lists the pets
+	// openapi:operation GET /pets listPets
+	// openapi:produces application/json
+	// openapi:response 200 Page[Pet] --- Page of pets
+	ListPets() (Page[Pet], error)
+}
+
+// Money is serialized as a decimal string, see types.yaml
+type Money struct {
+	units int64
+	nanos int32
+}
+
+// Event ...
+// openapi:schema
+type Event struct {
+	// openapi:description Time of the event
+	CreatedAt time.Time `json:"createdAt"`
+	// openapi:description Timeout of the event
+	Timeout *time.Duration `json:"timeout"`
+	// openapi:description Raw payload of the event
+	Payload json.RawMessage `json:"payload"`
+	// openapi:description Address of the source
+	Source net.IP `json:"source"`
+	// openapi:description Checksum of the payload
+	Checksum []byte `json:"checksum"`
+	// openapi:description Price of the event
+	Price Money `json:"price"`
+}
+
+// TreeNode ...
+// openapi:schema
+type TreeNode struct {
+	// openapi:description Name of the node
+	Name string `json:"name"`
+	// openapi:description Children of the node
+	Children []*TreeNode `json:"children"`
+}
+
+// Comment ...
+// openapi:schema
+type Comment struct {
+	// openapi:description Text of the comment
+	Text string `json:"text"`
+	// openapi:description Replies to the comment
+	Thread *Thread `json:"thread"`
+}
+
+// Thread ...
+type Thread struct {
+	// openapi:description Comments of the thread
+	Comments []Comment `json:"comments"`
+}
+
+// Node ...
+type Node[T any] struct {
+	// openapi:description Value of the node
+	Value T `json:"value"`
+	// openapi:description Children of the node
+	Children []Node[T] `json:"children"`
+}
+
+// Tree is inlined and cannot reference itself
+type Tree map[string]Tree
+
+// Folder ...
+// openapi:schema
+type Folder struct {
+	// openapi:description Generic tree of names
+	Nodes Node[string] `json:"nodes"`
+	// openapi:description Recursive inlined tree
+	Tree Tree `json:"tree"`
+}
+
+// CreateOrderRequest ...
+// openapi:schema
+type CreateOrderRequest struct {
+	// openapi:description Options of the order
+	Options struct {
+		// openapi:description Gift wrap the order
+		GiftWrap bool `json:"giftWrap"`
+		// openapi:description Delivery window
+		Delivery struct {
+			// openapi:description Earliest delivery
+			From string `json:"from"`
+		} `json:"delivery"`
+	} `json:"options"`
+	// openapi:description Order lines
+	Lines []struct {
+		// openapi:description Quantity of the line
+		Quantity int `json:"quantity"`
+	} `json:"lines"`
+	// openapi:description Order labels
+	Labels map[string]struct {
+		// openapi:description Color of the label
+		Color string `json:"color"`
+	} `json:"labels"`
+}
+
+// Account ...
+// openapi:schema
+type Account struct {
+	// openapi:description Untagged fields use the Go name
+	ID string
+	// openapi:description Unexported fields are not serialized
+	secret string
+	// openapi:description Fields declared together are separate properties
+	First, Last string `json:",omitempty"`
+	// openapi:description Numbers encoded as strings
+	Balance int64 `json:"balance,string"`
+	// openapi:description Ignored field
+	Internal string `json:"-"`
+	// openapi:description Field named dash
+	Dash string `json:"-,"`
+}
+
+// PagedPets ...
+// openapi:schema
+type PagedPets struct {
+	// openapi:description Embedded generic struct named by its json tag
+	Page[Pet] `json:"page"`
+}
+
+// Shipment ...
+// openapi:schema
+type Shipment struct {
+	// openapi:description Always serialized
+	ID string `json:"id"`
+	// openapi:description Optional pointer
+	Carrier *string `json:"carrier"`
+	// openapi:description Omitted when empty
+	Notes string `json:"notes,omitempty"`
+	// openapi:description Validated as required
+	Address string `json:"address,omitempty" validate:"required,min=3"`
+	// openapi:description Bound as required
+	Weight *int `json:"weight,omitempty" binding:"required"`
+	// openapi:description Annotated as required
+	// openapi:required
+	Tracking string `json:"tracking,omitzero"`
+	// openapi:description Annotated as optional
+	// openapi:required false
+	Label string `json:"label"`
+}
+
+// Signup ...
+// openapi:schema
+type Signup struct {
+	// openapi:description Login name
+	Username string `json:"username" validate:"required,min=1,max=64,alphanum"`
+	// openapi:description Contact email
+	Email string `json:"email" validate:"required,email"`
+	// openapi:description Pet kind
+	Kind string `json:"kind" binding:"oneof=cat dog"`
+	// openapi:description Request ID
+	RequestID string `json:"requestId" validate:"uuid4"`
+	// openapi:description Age in years
+	Age int `json:"age" validate:"gt=0,lte=130"`
+	// openapi:description Tags of the account
+	Tags []string `json:"tags" validate:"max=10,unique,dive,min=2"`
+	// openapi:description Referrer, validated at runtime only
+	Referrer string `json:"referrer" validate:"required_with=Email,excludesall=!"`
+}
+
+// Product ...
+// openapi:schema
+type Product struct {
+	// openapi:title Product ID
+	// openapi:readOnly
+	ID string `json:"id"`
+	// openapi:minimum 1
+	// openapi:maximum 100
+	// openapi:multipleOf 5
+	Quantity int `json:"quantity"`
+	// openapi:exclusiveMinimum 0
+	// openapi:maximum 9999.99
+	Price float64 `json:"price"`
+	// openapi:minLength 3
+	// openapi:maxLength 10
+	// openapi:pattern ^[A-Z]+$
+	Code string `json:"code"`
+	// openapi:minItems 1
+	// openapi:maxItems 5
+	// openapi:uniqueItems
+	Tags []string `json:"tags"`
+	// openapi:writeOnly
+	// openapi:minimum 1.5
+	// openapi:minLength 2
+	Secret string `json:"secret"`
+	// openapi:minimum 0.5
+	Count int `json:"count"`
+}
+
+// Listing ...
+// openapi:schema
+type Listing struct {
+	// openapi:example 3
+	// openapi:default 1
+	// openapi:enum 1, 2, 3
+	Rooms int `json:"rooms"`
+	// openapi:example 99.5
+	Rent float64 `json:"rent"`
+	// openapi:example true
+	// openapi:default false
+	Furnished bool `json:"furnished"`
+	// openapi:example ["pool", "garden"]
+	// openapi:enum pool,garden,garage
+	Features []string `json:"features"`
+	// openapi:example {"floor": 2}
+	Extra map[string]int `json:"extra"`
+	// openapi:example many
+	Floors int `json:"floors"`
+	// openapi:example 1
+	Street string `json:"street"`
+	// openapi:description Size of the rooms
+	// openapi:title Room size
+	// openapi:example 2
+	// openapi:default 1
+	// openapi:maximum 2
+	RoomSize Size `json:"roomSize"`
+}
+
+// Animal is the base of the animals
+// openapi:schema
+// openapi:discriminator kind cat=Cat dog=Dog
+type Animal struct {
+	// openapi:description Kind of the animal
+	Kind string `json:"kind"`
+}
+
+// Cat ...
+// openapi:schema
+type Cat struct {
+	// openapi:description Kind of the animal
+	Kind string `json:"kind"`
+	// openapi:description Whether the cat purrs
+	Purrs bool `json:"purrs"`
+}
+
+// Dog ...
+// openapi:schema
+type Dog struct {
+	// openapi:allOf
+	Animal
+	// openapi:description Whether the dog barks
+	Barks bool `json:"barks"`
+}
+
+// Bird has no kind
+type Bird struct {
+	// openapi:description Number of wings
+	Wings int `json:"wings"`
+}
+
+// Shelter ...
+// openapi:schema
+type Shelter struct {
+	// openapi:description Resident of the shelter
+	// openapi:oneOf Cat Dog
+	// openapi:discriminator kind cat=Cat dog=Dog
+	Resident json.RawMessage `json:"resident"`
+	// openapi:description Visitor of the shelter
+	// openapi:anyOf Cat Bird
+	// openapi:discriminator kind
+	Visitor json.RawMessage `json:"visitor"`
+	// openapi:description Cat with the base properties
+	// openapi:allOf Animal Cat
+	Mixed json.RawMessage `json:"mixed"`
+}
+
+// Shape is implemented by the shapes
+// openapi:discriminator type Kind()
+type Shape interface {
+	Kind() string
+	Area() float64
+}
+
+// Circle ...
+// openapi:schema
+type Circle struct {
+	// openapi:description Type of the shape
+	Type string `json:"type"`
+	// openapi:description Radius of the circle
+	Radius float64 `json:"radius"`
+}
+
+// Kind returns the discriminator value of circles
+func (Circle) Kind() string { return "circle" }
+
+// Area returns the area of the circle
+func (c Circle) Area() float64 { return 3.14 * c.Radius * c.Radius }
+
+// Square ...
+// openapi:schema
+type Square struct {
+	// openapi:description Type of the shape
+	Type string `json:"type"`
+	// openapi:description Side of the square
+	Side float64 `json:"side"`
+}
+
+// shapeSquare is the discriminator value of squares
+const shapeSquare = "square"
+
+// Kind returns the discriminator value of squares
+func (*Square) Kind() string { return shapeSquare }
+
+// Area returns the area of the square
+func (s *Square) Area() float64 { return s.Side * s.Side }
+
+// Notification is a marker interface for notifications
+type Notification interface{}
+
+// Email is not annotated with openapi:schema
+// openapi:implements Notification
+type Email struct {
+	// openapi:description Recipient address
+	To string `json:"to"`
+}
+
+// Drawing ...
+// openapi:schema
+type Drawing struct {
+	// openapi:description Shape of the drawing
+	Shape Shape `json:"shape"`
+	// openapi:description Notification sent for the drawing
+	Notification Notification `json:"notification"`
+}
+
+// Version is serialized as `major.minor.patch`
+type Version struct {
+	Major, Minor, Patch int
+}
+
+// MarshalText implements encoding.TextMarshaler
+func (v Version) MarshalText() ([]byte, error) { return nil, nil }
+
+// Amount is serialized as a decimal number
+// openapi:schema
+// openapi:type number double
+type Amount struct {
+	units int64
+	nanos int32
+}
+
+// MarshalJSON implements json.Marshaler
+func (a Amount) MarshalJSON() ([]byte, error) { return nil, nil }
+
+// Opaque has a custom encoding that is not documented
+type Opaque struct {
+	Data string `json:"data"`
+}
+
+// UnmarshalJSON implements json.Unmarshaler
+func (o *Opaque) UnmarshalJSON([]byte) error { return nil }
+
+// Release ...
+// openapi:schema
+type Release struct {
+	// openapi:description Version of the release
+	Version Version `json:"version"`
+	// openapi:description Price of the release
+	Price Amount `json:"price"`
+	// openapi:description Opaque payload
+	Payload Opaque `json:"payload"`
+	// openapi:description Build number
+	// openapi:type string int64
+	Build int64 `json:"build"`
+}
+
+// Counter is a named unsigned integer
+type Counter uint16
+
+// Measurement ...
+// openapi:schema
+type Measurement struct {
+	// openapi:description Identifier
+	ID int `json:"id"`
+	// openapi:description Level
+	Level int8 `json:"level"`
+	// openapi:description Offset
+	Offset int16 `json:"offset"`
+	// openapi:description Channel
+	Channel uint8 `json:"channel"`
+	// openapi:description Sequence
+	Sequence uint32 `json:"sequence"`
+	// openapi:description Total
+	Total uint64 `json:"total"`
+	// openapi:description Ratio
+	Ratio float32 `json:"ratio"`
+	// openapi:description Value
+	Value float64 `json:"value"`
+	// openapi:description Character
+	Char rune `json:"char"`
+	// openapi:description Named counter
+	Counter Counter `json:"counter"`
+	// openapi:description Optional small number
+	Small *int32 `json:"small"`
+}
+
+// PetPatch ...
+// openapi:schema
+type PetPatch struct {
+	// openapi:description New name
+	Name *string `json:"name"`
+	// openapi:description Omitted when not changed
+	Status *PetStatus `json:"status,omitempty"`
+	// openapi:description New tags
+	Tags []string `json:"tags"`
+	// openapi:description New best friend
+	Friend *Cat `json:"friend"`
+	// openapi:description New nickname
+	// openapi:nullable
+	Nickname string `json:"nickname"`
+	// openapi:description New age
+	// openapi:nullable false
+	Age *int `json:"age"`
+}
+
+// Pets ...
+// openapi:schema
+// openapi:description Pets of a kennel
+type Pets []Pet
+
+// Labels ...
+// openapi:schema
+// openapi:description Labels attached to a kennel
+type Labels map[string]string
+
+// Forest ...
+// openapi:schema
+type Forest []Grove
+
+// Grove ...
+// openapi:schema
+type Grove struct {
+	// openapi:description Name of the grove
+	Name string `json:"name"`
+	// openapi:description Groves within the grove
+	Groves Forest `json:"groves"`
+}
+
+// Kennel ...
+// openapi:schema
+type Kennel struct {
+	// openapi:description Pets in the kennel
+	Pets Pets `json:"pets"`
+	// openapi:description Labels of the kennel
+	Labels Labels `json:"labels"`
+	// openapi:description Pets by run
+	Runs []Pets `json:"runs"`
+	// openapi:description Annotations of the kennel
+	Annotations Annotations `json:"annotations"`
+}
+
+// Webhook ...
+// openapi:schema
+// openapi:yaml start
+//
+//	externalDocs:
+//	  url: https://example.com/webhooks
+//	x-internal: true
+//
+// openapi:yaml end
+type Webhook struct {
+	// openapi:description Target of the webhook
+	// openapi:yaml start
+	//
+	//	format: uri
+	//	maxLength: 2048
+	//
+	// openapi:yaml end
+	URL string `json:"url"`
+	// openapi:description Pet sent with the webhook
+	// openapi:yaml start
+	//	x-sample: true
+	// openapi:yaml end
+	Pet Pet `json:"pet"`
+}
+
+// WebhooksInterface ...
+type WebhooksInterface interface {
+	// CreateWebhook Creates a webhook
+	// openapi:operation POST /webhooks createWebhook
+	// openapi:body Webhook --- Webhook to create
+	// openapi:response 201 Webhook --- Created webhook
+	// openapi:yaml start
+	//	callbacks:
+	//	  delivery:
+	//	    "{$request.body#/url}":
+	//	      post:
+	//	        responses:
+	//	          "204":
+	//	            description: Delivered
+	//	responses:
+	//	  "201":
+	//	    headers:
+	//	      Location:
+	//	        schema:
+	//	          type: string
+	//	x-rate-limit: 10
+	// openapi:yaml end
+	CreateWebhook(Webhook) (Webhook, error)
+}
+
+// Color is the color of a lamp, encoded by its name
+type Color int
+
+const (
+	// ColorRed is the color of stop signs
+	ColorRed Color = iota
+	// ColorGreen is the color of grass
+	ColorGreen
+)
+
+func (c Color) String() string {
+	switch c {
+	case ColorRed:
+		return "red"
+	case ColorGreen:
+		return "green"
+	}
+	return "unknown"
+}
+
+// MarshalText encodes the color by its name
+func (c Color) MarshalText() ([]byte, error) {
+	return []byte(c.String()), nil
+}
+
+// Priority is the priority of a lamp, encoded by its name
+type Priority uint8
+
+const (
+	PriorityLow  Priority = 1
+	PriorityHigh Priority = 2
+)
+
+var priorityNames = map[Priority]string{PriorityLow: "low", PriorityHigh: "high"}
+
+func (p Priority) String() string {
+	return priorityNames[p]
+}
+
+// MarshalText encodes the priority by its name
+func (p Priority) MarshalText() ([]byte, error) {
+	return []byte(p.String()), nil
+}
+
+// Lamp ...
+// openapi:schema
+type Lamp struct {
+	// openapi:description Color of the lamp
+	Color Color `json:"color"`
+	// openapi:description Priority of the lamp
+	Priority Priority `json:"priority"`
+}
@@ -16,7 +16,11 @@
 
 package main
 
-import "encoding/json"
+import (
+	"encoding/json"
+
+	"github.com/vasusheoran/go-openapi/scan/testdata/pets/errors"
+)
 
 // CreatePetResponse ...
 // openapi:schema
@@ -36,6 +40,8 @@ type CreatePetResponse struct {
 	// openapi:nullable
 	//// openapi:name category,  this is not read as of now using test/main.go
 	Type Category `json:"category"`
+	// openapi:description Error returned while creating the pet
+	Error *errors.ErrorResponse `json:"error"`
 }
 
 // GetPetByIDResponse ...
@@ -129,6 +135,7 @@ type PetsInterface interface {
 	// openapi:param petId path string true --- ID of pet that needs to be updated
 	// openapi:param x-agent-id header string true --- Agent ID for the request
 	// openapi:response 200 CreatePetResponse --- OK
+	// openapi:response 400 errors.ErrorResponse --- Bad request
 	CreatePet(name string) (*CreatePetResponse, error)
 }
 
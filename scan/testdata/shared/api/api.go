@@ -0,0 +1,10 @@
+package api
+
+import "github.com/vasusheoran/go-openapi/scan/testdata/shared/levels"
+
+// Event ...
+// openapi:schema
+type Event struct {
+	// openapi:description Severity of the event
+	Level levels.Level `json:"level"`
+}
@@ -0,0 +1,25 @@
+package levels
+
+// Level is the severity of an event, encoded by its name
+type Level int
+
+const (
+	// LevelLow is logged
+	LevelLow Level = iota
+	// LevelHigh is paged
+	LevelHigh
+)
+
+func (l Level) String() string {
+	switch l {
+	case LevelLow:
+		return "low"
+	case LevelHigh:
+		return "high"
+	}
+	return "unknown"
+}
+
+func (l Level) MarshalText() ([]byte, error) {
+	return []byte(l.String()), nil
+}
@@ -4,7 +4,10 @@ import (
 	"github.com/getkin/kin-openapi/openapi3"
 	"go/ast"
 	"path/filepath"
+	"reflect"
+	"sort"
 	"strings"
+	"unicode"
 )
 
 // getParametersFromMethodComments extracts information about the request params from the interface method comments.
@@ -17,9 +20,7 @@ func getParametersFromMethodComments(pc []*Parameter) openapi3.Parameters {
 			Required:    param.Required == "true",
 			In:          param.In,
 			Schema: &openapi3.SchemaRef{
-				Value: &openapi3.Schema{
-					Type: param.Type,
-				},
+				Value: getParameterSchema(param.Type),
 			},
 		}
 		parametersRefs = append(parametersRefs, &openapi3.ParameterRef{
@@ -29,6 +30,14 @@ func getParametersFromMethodComments(pc []*Parameter) openapi3.Parameters {
 	return parametersRefs
 }
 
+// getParameterSchema returns the schema of a parameter type, which is either a Go basic type or an OpenAPI type.
+func getParameterSchema(paramType string) *openapi3.Schema {
+	if schema := getBasicSchema(paramType); schema != nil {
+		return schema
+	}
+	return &openapi3.Schema{Type: paramType}
+}
+
 // getRequestBodyFromOperation extracts information about the request type from the method comments.
 func getRequestBodyFromOperation(schema *openapi3.Schema, op *openAPIOperation) *openapi3.RequestBodyRef {
 	return &openapi3.RequestBodyRef{
@@ -70,12 +79,11 @@ func getKey(base, name, field string) string {
 		return filepath.Join(base, name, field)
 	} else if len(base) > 0 {
 		return filepath.Join(base, name)
-	} else {
-		return filepath.Join(name, field)
 	}
-	return name
+	return filepath.Join(name, field)
 }
 
+// parseJSONTag returns the name and the options of a json struct tag.
 func parseJSONTag(tag string) (string, tagOptions) {
 	options := make(tagOptions)
 
@@ -83,7 +91,7 @@ func parseJSONTag(tag string) (string, tagOptions) {
 	name := parts[0]
 
 	for _, part := range parts[1:] {
-		if part == "omitempty" {
+		if len(part) > 0 {
 			options[part] = true
 		}
 	}
@@ -91,22 +99,122 @@ func parseJSONTag(tag string) (string, tagOptions) {
 	return name, options
 }
 
+// isValidJSONName reports whether name can be used as a property name by encoding/json.
+func isValidJSONName(name string) bool {
+	for _, c := range name {
+		switch {
+		case strings.ContainsRune("!#$%&()*+-./:;<=>?@[]^_{|}~ ", c):
+			// Backslash and quote chars are reserved, but otherwise any punctuation chars are allowed
+		case !unicode.IsLetter(c) && !unicode.IsDigit(c):
+			return false
+		}
+	}
+	return len(name) > 0
+}
+
+// getFieldName returns the Go name of the field. Embedded fields are named after their type, without the
+// type arguments of generic types.
+func getFieldName(field *ast.Field) string {
+	if len(field.Names) > 0 {
+		return field.Names[0].Name
+	}
+
+	expr := field.Type
+	if star, ok := expr.(*ast.StarExpr); ok {
+		expr = star.X
+	}
+	switch t := expr.(type) {
+	case *ast.IndexExpr:
+		expr = t.X
+	case *ast.IndexListExpr:
+		expr = t.X
+	}
+	switch t := expr.(type) {
+	case *ast.Ident:
+		return t.Name
+	case *ast.SelectorExpr:
+		return t.Sel.Name
+	}
+	return ""
+}
+
+// getFieldNames returns the Go names of the field, e.g. `A` and `B` for `A, B int`.
+func getFieldNames(field *ast.Field) []string {
+	if len(field.Names) == 0 {
+		return []string{getFieldName(field)}
+	}
+	names := make([]string, 0, len(field.Names))
+	for _, name := range field.Names {
+		names = append(names, name.Name)
+	}
+	return names
+}
+
+// getJSONTag returns the json tag of the field.
+func getJSONTag(field *ast.Field) string {
+	return getTag(field, "json")
+}
+
+// getTag returns the value of the struct tag key of the field.
+func getTag(field *ast.Field, key string) string {
+	if field.Tag == nil {
+		return ""
+	}
+	return reflect.StructTag(field.Tag.Value[1 : len(field.Tag.Value)-1]).Get(key)
+}
+
+// hasAnnotation reports whether the comment group contains the annotation.
+func hasAnnotation(cg *ast.CommentGroup, annotation string) bool {
+	return findDirective(cg, strings.TrimPrefix(annotation, annotationPrefix)) != nil
+}
+
+// getAnnotationValue returns the text following the annotation in the comment group.
+func getAnnotationValue(cg *ast.CommentGroup, annotation string) (string, bool) {
+	d := findDirective(cg, strings.TrimPrefix(annotation, annotationPrefix))
+	if d == nil {
+		return "", false
+	}
+	return d.Text, true
+}
+
+func sortedKeys(m openapi3.Schemas) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// exported returns name with an upper case first letter, e.g. `Pet` for `pet`.
+func exported(name string) string {
+	if len(name) == 0 {
+		return name
+	}
+	return strings.ToUpper(name[:1]) + name[1:]
+}
+
+func isInterface(ts *ast.TypeSpec) bool {
+	_, ok := ts.Type.(*ast.InterfaceType)
+	return ok
+}
+
 func getOpenAPIFieldType(expr ast.Expr) string {
 	switch t := expr.(type) {
 	case *ast.Ident:
-		switch t.Name {
-		case "bool":
-			return "boolean"
-		case "string", "error":
+		if t.Name == "error" {
 			return "string"
-		case "int", "int8", "int16", "int32", "uint", "uint8", "uint16", "uint32", "float32", "float64":
-			return "number"
-		default:
-			return "object"
 		}
+		if schema := getBasicSchema(t.Name); schema != nil {
+			return schema.Type
+		}
+		return "object"
 	case *ast.ArrayType:
+		if elt, ok := t.Elt.(*ast.Ident); ok && t.Len == nil && (elt.Name == "byte" || elt.Name == "uint8") {
+			return "string"
+		}
 		return "array"
-	case *ast.MapType:
+	case *ast.MapType, *ast.StructType:
 		return "object"
 	case *ast.StarExpr:
 		return getOpenAPIFieldType(t.X)
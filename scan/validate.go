@@ -0,0 +1,212 @@
+package scan
+
+import (
+	"go/ast"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// validationTags are the struct tags of go-playground/validator and gin.
+var validationTags = []string{"validate", "binding"}
+
+// validationFormats maps validator rules to string formats.
+var validationFormats = map[string]string{
+	"email":            "email",
+	"url":              "uri",
+	"uri":              "uri",
+	"uuid":             "uuid",
+	"uuid3":            "uuid",
+	"uuid4":            "uuid",
+	"uuid5":            "uuid",
+	"ipv4":             "ipv4",
+	"ipv6":             "ipv6",
+	"hostname":         "hostname",
+	"hostname_rfc1123": "hostname",
+	"fqdn":             "hostname",
+	"base64":           "byte",
+}
+
+// validationPatterns maps validator rules to string patterns.
+var validationPatterns = map[string]string{
+	"alpha":       `^[a-zA-Z]+$`,
+	"alphanum":    `^[a-zA-Z0-9]+$`,
+	"numeric":     `^[-+]?[0-9]+(?:\.[0-9]+)?$`,
+	"number":      `^[0-9]+$`,
+	"hexadecimal": `^(0[xX])?[0-9a-fA-F]+$`,
+	"e164":        `^\+[1-9]?[0-9]{7,14}$`,
+}
+
+// applyValidation translates the rules of the validation tags of field into constraints of the field
+// schema. Rules that cannot be expressed in the schema are reported.
+func (p *Parser) applyValidation(key string, field *ast.Field, schemaRef *openapi3.SchemaRef) {
+	for _, tag := range validationTags {
+		rules := getTag(field, tag)
+		if len(rules) == 0 {
+			continue
+		}
+		if unmapped := applyValidationRules(schemaRef, strings.Split(rules, ",")); len(unmapped) > 0 {
+			p.warn(field.Tag.Pos(), "", "%s rules `%s` of %s are not documented in the schema", tag, strings.Join(unmapped, ","), key)
+		}
+	}
+}
+
+// applyValidationRules applies rules to the schema and returns the rules that could not be applied.
+// Rules following `dive` apply to the items of slices and the values of maps.
+func applyValidationRules(schemaRef *openapi3.SchemaRef, rules []string) []string {
+	var unmapped []string
+	for i, rule := range rules {
+		if rule != "dive" {
+			if !applyValidationRule(schemaRef, rule) {
+				unmapped = append(unmapped, rule)
+			}
+			continue
+		}
+
+		var items *openapi3.SchemaRef
+		if schemaRef.Value != nil && len(schemaRef.Ref) == 0 {
+			items = schemaRef.Value.Items
+			if items == nil {
+				items = schemaRef.Value.AdditionalProperties.Schema
+			}
+		}
+		if items == nil {
+			return append(unmapped, rules[i:]...)
+		}
+		return append(unmapped, applyValidationRules(items, rules[i+1:])...)
+	}
+	return unmapped
+}
+
+// applyValidationRule applies a single rule to the schema and reports whether it could be applied.
+func applyValidationRule(schemaRef *openapi3.SchemaRef, rule string) bool {
+	name, param, _ := strings.Cut(rule, "=")
+	switch name {
+	case "", "required", "omitempty", "omitnil", "omitzero":
+		// Required fields are listed by the enclosing schema
+		return true
+	}
+
+	// Constraints of referenced schemas would apply to every use of the component
+	schema := schemaRef.Value
+	if schema == nil || len(schemaRef.Ref) > 0 {
+		return false
+	}
+
+	switch name {
+	case "min", "gte":
+		return setBound(schema, param, true, false)
+	case "gt":
+		return setBound(schema, param, true, true)
+	case "max", "lte":
+		return setBound(schema, param, false, false)
+	case "lt":
+		return setBound(schema, param, false, true)
+	case "len":
+		return setBound(schema, param, true, false) && setBound(schema, param, false, false)
+	case "oneof":
+		return setEnum(schema, strings.Fields(param))
+	case "unique":
+		schema.UniqueItems = schema.Type == "array"
+		return schema.UniqueItems
+	case "startswith":
+		return setPattern(schema, "^"+regexp.QuoteMeta(param))
+	case "endswith":
+		return setPattern(schema, regexp.QuoteMeta(param)+"$")
+	}
+
+	if format, ok := validationFormats[name]; ok && schema.Type == "string" {
+		schema.Format = format
+		return true
+	}
+	if pattern, ok := validationPatterns[name]; ok {
+		return setPattern(schema, pattern)
+	}
+	return false
+}
+
+// setBound sets the lower or upper bound of numbers, or the length of strings, arrays and objects.
+// Exclusive bounds of numbers are flagged as in OpenAPI 3.0 and converted by ToDocument for OpenAPI 3.1.
+func setBound(schema *openapi3.Schema, param string, lower, exclusive bool) bool {
+	switch schema.Type {
+	case "integer", "number":
+		v, err := strconv.ParseFloat(param, 64)
+		if err != nil {
+			return false
+		}
+		if lower {
+			schema.Min, schema.ExclusiveMin = &v, exclusive
+		} else {
+			schema.Max, schema.ExclusiveMax = &v, exclusive
+		}
+		return true
+	case "string", "array", "object":
+		n, err := strconv.ParseUint(param, 10, 64)
+		if err != nil {
+			return false
+		}
+		if exclusive && lower {
+			n++
+		} else if exclusive {
+			if n == 0 {
+				return false
+			}
+			n--
+		}
+
+		switch {
+		case schema.Type == "string" && lower:
+			schema.MinLength = n
+		case schema.Type == "string":
+			schema.MaxLength = &n
+		case schema.Type == "array" && lower:
+			schema.MinItems = n
+		case schema.Type == "array":
+			schema.MaxItems = &n
+		case lower:
+			schema.MinProps = n
+		default:
+			schema.MaxProps = &n
+		}
+		return true
+	}
+	return false
+}
+
+// setEnum sets the enum of the schema to values converted to the schema type.
+func setEnum(schema *openapi3.Schema, values []string) bool {
+	enum := make([]interface{}, 0, len(values))
+	for _, value := range values {
+		switch schema.Type {
+		case "integer":
+			v, err := strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				return false
+			}
+			enum = append(enum, v)
+		case "number":
+			v, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				return false
+			}
+			enum = append(enum, v)
+		case "string":
+			enum = append(enum, value)
+		default:
+			return false
+		}
+	}
+	schema.Enum = enum
+	return true
+}
+
+// setPattern sets the pattern of string schemas.
+func setPattern(schema *openapi3.Schema, pattern string) bool {
+	if schema.Type != "string" {
+		return false
+	}
+	schema.Pattern = pattern
+	return true
+}
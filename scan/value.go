@@ -0,0 +1,97 @@
+package scan
+
+import (
+	"encoding/json"
+	"fmt"
+	"go/ast"
+	"strconv"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// applyValues sets the example, default and enum annotations of the field decoded against its schema.
+// Values that do not fit the schema are reported and skipped.
+func (p *Parser) applyValues(key string, field *ast.Field, fc *fieldComment, schema *openapi3.Schema) {
+	if len(fc.Example) > 0 {
+		if value, ok := p.decodeAnnotationValue(key, field, fc, "example", schema, fc.Example); ok {
+			schema.Example = value
+		}
+	}
+	if len(fc.Default) > 0 {
+		if value, ok := p.decodeAnnotationValue(key, field, fc, "default", schema, fc.Default); ok {
+			schema.Default = value
+		}
+	}
+	if len(fc.Enum) == 0 {
+		return
+	}
+
+	// The enum of slices lists the values of the items
+	target := schema
+	if schema.Type == "array" && schema.Items != nil && schema.Items.Value != nil && len(schema.Items.Ref) == 0 {
+		target = schema.Items.Value
+	}
+	enum := make([]interface{}, 0, len(fc.Enum))
+	for _, literal := range fc.Enum {
+		if value, ok := p.decodeAnnotationValue(key, field, fc, "enum", target, literal); ok {
+			enum = append(enum, value)
+		}
+	}
+	target.Enum = enum
+}
+
+// decodeAnnotationValue decodes the literal of the annotation of the field and reports it at the annotation comment if it is invalid.
+func (p *Parser) decodeAnnotationValue(key string, field *ast.Field, fc *fieldComment, annotation string, schema *openapi3.Schema, literal string) (interface{}, bool) {
+	value, err := decodeValue(schema.Type, p.isInteger(field.Type), literal)
+	if err != nil {
+		p.warn(fc.Positions[annotation], annotationPrefix+annotation, "invalid value for %s: %s", key, err)
+		return nil, false
+	}
+	return value, true
+}
+
+// decodeValue decodes the literal of an annotation as a value of the schema type. Numbers of integer
+// Go types must be integers, arrays and objects are JSON literals.
+func decodeValue(schemaType string, integer bool, literal string) (interface{}, error) {
+	switch schemaType {
+	case "string":
+		return literal, nil
+	case "integer", "number":
+		if integer || schemaType == "integer" {
+			v, err := strconv.ParseInt(literal, 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("%s is not an integer", literal)
+			}
+			return v, nil
+		}
+		v, err := strconv.ParseFloat(literal, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%s is not a number", literal)
+		}
+		return v, nil
+	case "boolean":
+		v, err := strconv.ParseBool(literal)
+		if err != nil {
+			return nil, fmt.Errorf("%s is not a boolean", literal)
+		}
+		return v, nil
+	case "array", "object":
+		var v interface{}
+		if err := json.Unmarshal([]byte(literal), &v); err != nil {
+			return nil, fmt.Errorf("%s is not a JSON %s", literal, schemaType)
+		}
+		_, isArray := v.([]interface{})
+		_, isObject := v.(map[string]interface{})
+		if (schemaType == "array" && !isArray) || (schemaType == "object" && !isObject) {
+			return nil, fmt.Errorf("%s is not a JSON %s", literal, schemaType)
+		}
+		return v, nil
+	}
+
+	// Values of untyped schemas are JSON literals or strings
+	var v interface{}
+	if err := json.Unmarshal([]byte(literal), &v); err != nil {
+		return literal, nil
+	}
+	return v, nil
+}